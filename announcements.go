@@ -2,12 +2,10 @@ package chawk
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
-	"strings"
 
 	endpoints "github.com/sugarvoid/chawk/endpoints"
 )
@@ -16,6 +14,8 @@ type AnnouncementService struct {
 	client *BlackboardClient
 }
 
+var ErrAnnouncementNotFound = errors.New("announcement doesn't exist")
+
 type Duration struct {
 	Type  *string `json:"type"`
 	Start *string `json:"start"`
@@ -40,60 +40,27 @@ type Announcement struct {
 }
 
 // GetAllAnnouncements returns all the announcements from a course
-func (c *AnnouncementService) GetAllAnnouncements(ctx context.Context, courseID string) ([]Announcement, error) {
-	courseID, err := RequiredString(courseID, "courseID")
+func (c *AnnouncementService) GetAllAnnouncements(ctx context.Context, course CourseRef) ([]Announcement, error) {
+	path, err := coursePath(course)
 	if err != nil {
 		return nil, err
 	}
 
-	url := endpoints.Announcements.GetAllByCourseId(courseID)
-
-	var allAnnouncements []Announcement
-
-	for {
-		resp, err := c.client.Get(ctx, url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get announcements: %w", err)
-		}
-
-		body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		resp.Body.Close()
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-		}
-
-		var result struct {
-			Results []Announcement `json:"results"`
-			Paging  struct {
-				NextPage string `json:"nextPage"`
-			} `json:"paging"`
-		}
-
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
+	pager := NewPager[Announcement](c.client, endpoints.Announcements.GetAll(path))
+	pager.NotFound = ErrCourseNotFound
 
-		allAnnouncements = append(allAnnouncements, result.Results...)
-
-		if result.Paging.NextPage == "" {
-			break
-		}
-		url = result.Paging.NextPage
+	announcements, err := collect(pager.All(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get announcements: %w", err)
 	}
 
-	return allAnnouncements, nil
+	return announcements, nil
 }
 
 // GetAnnouncement get a single announcement by its ID
-func (c *AnnouncementService) GetAnnouncement(ctx context.Context, courseID string, announcementID string) (Announcement, error) {
+func (c *AnnouncementService) GetAnnouncement(ctx context.Context, course CourseRef, announcementID string) (Announcement, error) {
 	// TODO: implement Blackboard announcement get
-	courseID = strings.TrimSpace(courseID)
-	exists, _ := c.client.Courses.DoesCourseExist(ctx, courseID)
+	exists, _ := c.client.Courses.DoesCourseExist(ctx, course)
 
 	if !exists {
 		return Announcement{}, ErrCourseNotFound
@@ -104,13 +71,18 @@ func (c *AnnouncementService) GetAnnouncement(ctx context.Context, courseID stri
 	return Announcement{}, errors.New("GetAnnouncement not implemented")
 }
 
-func (c *AnnouncementService) UpdateAnnouncement(ctx context.Context, courseID string, announcementID string) error {
+func (c *AnnouncementService) UpdateAnnouncement(ctx context.Context, course CourseRef, announcementID string) error {
 	// TODO: implement
 	return errors.New("UpdateAnnouncement not implemented")
 }
 
-func (c *AnnouncementService) DeleteAnnouncement(ctx context.Context, courseID, announcementID string) error {
-	url := endpoints.Announcements.DeleteById(courseID, announcementID)
+func (c *AnnouncementService) DeleteAnnouncement(ctx context.Context, course CourseRef, announcementID string) error {
+	path, err := coursePath(course)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.Announcements.Delete(path, announcementID)
 
 	resp, err := c.client.Delete(ctx, url)
 	if err != nil {
@@ -121,24 +93,23 @@ func (c *AnnouncementService) DeleteAnnouncement(ctx context.Context, courseID,
 	switch resp.StatusCode {
 	case http.StatusNoContent:
 		return nil
-	case http.StatusForbidden:
-		return ErrInsufficientPrivileges
 	case http.StatusNotFound:
-		return fmt.Errorf("announcement %s not found in course %s", announcementID, courseID)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, ErrAnnouncementNotFound)
 	default:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp, body, nil)
 	}
 }
 
-func (c *AnnouncementService) DeleteAllAnnouncements(ctx context.Context, courseID string) error {
-	announcements, err := c.GetAllAnnouncements(ctx, courseID)
+func (c *AnnouncementService) DeleteAllAnnouncements(ctx context.Context, course CourseRef) error {
+	announcements, err := c.GetAllAnnouncements(ctx, course)
 	if err != nil {
 		return fmt.Errorf("failed to get announcements: %w", err)
 	}
 
 	for _, a := range announcements {
-		if err := c.DeleteAnnouncement(ctx, courseID, a.ID); err != nil {
+		if err := c.DeleteAnnouncement(ctx, course, a.ID); err != nil {
 			return fmt.Errorf("failed to delete announcement %s: %w", a.ID, err)
 		}
 		//fmt.Printf("Deleted announcement %q\n", a.Title)
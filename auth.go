@@ -8,6 +8,10 @@ type Token struct {
 	RefreshToken string    `json:"refresh_token,omitempty"`
 	ExpiresIn    int64     `json:"expires_in"`
 	Expiry       time.Time `json:"expiry"`
+
+	// Only set for user tokens from the authorization code flow
+	Scope  string `json:"scope,omitempty"`
+	UserID string `json:"user_id,omitempty"`
 }
 
 func (t *Token) IsExpired() bool {
@@ -0,0 +1,136 @@
+package chawk
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Helpers shared by the bulk operations (imports, rollovers, rosters).
+
+// DefaultConcurrency is how many calls a bulk operation (Import,
+// LinkObservers, Rollover, ApplyRoster) makes at once when its concurrency
+// is 0. Learn starts throttling well before this matters.
+const DefaultConcurrency = 4
+
+// forEach calls fn(i, nil) for every index in [0, n) on at most limit
+// goroutines and waits for them all. Once ctx is done no new calls are
+// started; the indices left over get fn(i, ctx.Err()) instead, after the
+// rest have finished, so every index ends up with a result.
+func forEach(ctx context.Context, n, limit int, fn func(i int, err error)) {
+	if limit <= 0 {
+		limit = DefaultConcurrency
+	}
+
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(limit, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				fn(i, nil)
+			}
+		}()
+	}
+
+	next := 0
+	for ; next < n && ctx.Err() == nil; next++ {
+		work <- next
+	}
+	close(work)
+	wg.Wait()
+
+	for i := next; i < n; i++ {
+		fn(i, ctx.Err())
+	}
+}
+
+// listSeparator splits a list in one CSV cell, since the cells themselves
+// are comma separated.
+const listSeparator = ";"
+
+// splitList splits a cell on listSeparator, dropping empty items.
+func splitList(cell string) []string {
+	var out []string
+	for _, v := range strings.Split(cell, listSeparator) {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// csvTable is a CSV file with a header row, read into memory.
+type csvTable struct {
+	columns map[string]int // header -> index
+	headers []string
+	rows    []csvRow
+}
+
+type csvRow struct {
+	line   int // 1-based line in the file, for reports
+	fields []string
+}
+
+// readCSV reads a CSV file whose first row names the columns. Headers and
+// cells are trimmed, and a UTF-8 BOM from Excel is dropped.
+func readCSV(r io.Reader) (*csvTable, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, errors.New("csv is empty")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv header: %w", err)
+	}
+
+	t := &csvTable{columns: map[string]int{}}
+	for i, h := range header {
+		h = strings.TrimSpace(strings.TrimPrefix(h, "\ufeff"))
+		if _, dup := t.columns[h]; dup {
+			return nil, fmt.Errorf("csv column %q appears twice", h)
+		}
+		t.columns[h] = i
+		t.headers = append(t.headers, h)
+	}
+
+	for {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		t.rows = append(t.rows, csvRow{line: line, fields: fields})
+	}
+
+	return t, nil
+}
+
+// has reports whether the file has column.
+func (t *csvTable) has(column string) bool {
+	_, ok := t.columns[column]
+	return ok
+}
+
+// get returns the cell of row in column, or "" if there is no such column.
+func (t *csvTable) get(row csvRow, column string) string {
+	i, ok := t.columns[column]
+	if !ok || i >= len(row.fields) {
+		return ""
+	}
+	return row.fields[i]
+}
@@ -0,0 +1,75 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		name     string
+		n, limit int
+		cancelAt int // cancel ctx inside this call, -1 for never
+	}{
+		{name: "all run", n: 20, limit: 3, cancelAt: -1},
+		{name: "default limit", n: 20, cancelAt: -1},
+		{name: "more workers than work", n: 2, limit: 8, cancelAt: -1},
+		{name: "none", n: 0, cancelAt: -1},
+		{name: "cancelled", n: 50, limit: 1, cancelAt: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			var mu sync.Mutex
+			calls := make([]int, tt.n)
+			errs := make([]error, tt.n)
+			running, peak := 0, 0
+
+			forEach(ctx, tt.n, tt.limit, func(i int, err error) {
+				mu.Lock()
+				calls[i]++
+				errs[i] = err
+				running++
+				peak = max(peak, running)
+				mu.Unlock()
+
+				if i == tt.cancelAt {
+					cancel()
+				}
+
+				mu.Lock()
+				running--
+				mu.Unlock()
+			})
+
+			limit := tt.limit
+			if limit <= 0 {
+				limit = DefaultConcurrency
+			}
+			if peak > limit {
+				t.Errorf("%d calls at once, limit %d", peak, limit)
+			}
+
+			skipped := 0
+			for i := range tt.n {
+				if calls[i] != 1 {
+					t.Errorf("index %d called %d times", i, calls[i])
+				}
+				if errs[i] != nil {
+					skipped++
+					if !errors.Is(errs[i], context.Canceled) {
+						t.Errorf("index %d: err = %v, want context.Canceled", i, errs[i])
+					}
+				}
+			}
+			if (tt.cancelAt >= 0) != (skipped > 0) {
+				t.Errorf("%d indices skipped", skipped)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package chawk_test
+
+import (
+	"testing"
+
+	"github.com/sugarvoid/chawk"
+	"github.com/sugarvoid/chawk/chawktest"
+)
+
+// newTestServer starts a fake Learn with a client for it; both go away
+// when the test ends.
+func newTestServer(t *testing.T, opts ...chawk.Option) (*chawktest.Server, *chawk.BlackboardClient) {
+	t.Helper()
+
+	srv := chawktest.NewServer()
+	t.Cleanup(srv.Close)
+
+	client, err := srv.NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return srv, client
+}
+
+// mustDo fails the test on a setup error.
+func mustDo(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,292 @@
+package chawktest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces every scrubbed value in a cassette.
+const Redacted = "REDACTED"
+
+// Cassette is a recorded list of request/response pairs. Only the path and
+// query of each request are kept, so a cassette replays against any base URL.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is one recorded call.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"` // path and query
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// LoadCassette reads a cassette written by Recorder.Save.
+func LoadCassette(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+
+	var c Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+	return &c, nil
+}
+
+// Save writes the cassette as indented JSON, creating the directory if needed.
+func (c *Cassette) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// Scrubber decides what is removed from a cassette before it is saved.
+// Matching is case-insensitive. JSON fields are scrubbed at any depth, in
+// request and response bodies alike, and string values that are Learn paths
+// or URLs (paging.nextPage, say) are scrubbed like request URLs.
+type Scrubber struct {
+	Headers     []string
+	QueryParams []string
+	JSONFields  []string
+
+	// PathIDs are the ID kinds whose values are scrubbed from the path, so
+	// /users/userName:jdoe is saved as /users/userName:REDACTED.
+	PathIDs []string
+}
+
+// DefaultScrubber removes credentials, tokens and the personal fields of
+// Learn users.
+func DefaultScrubber() Scrubber {
+	return Scrubber{
+		Headers: []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"},
+		QueryParams: []string{
+			"code", "refresh_token", "code_verifier", "client_secret", "redirect_uri",
+			"userName", "externalId", "studentId", "name.given", "name.family", "contact.email",
+		},
+		JSONFields: []string{
+			"access_token", "refresh_token", "id_token", "password",
+			"userName", "externalId",
+			"email", "institutionEmail", "given", "family", "middle", "other", "suffix",
+			"preferredDisplayName", "birthDate", "studentId",
+			"homePhone", "mobilePhone", "businessPhone", "businessFax",
+			"street1", "street2", "city", "state", "zipCode", "country",
+		},
+		PathIDs: []string{"userName", "externalId"},
+	}
+}
+
+func (s Scrubber) header(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range s.Headers {
+		if _, ok := out[http.CanonicalHeaderKey(name)]; ok {
+			out.Set(name, Redacted)
+		}
+	}
+	return out
+}
+
+func (s Scrubber) url(u *url.URL) string {
+	q := u.Query()
+	for k := range q {
+		if containsFold(s.QueryParams, k) {
+			q.Set(k, Redacted)
+		}
+	}
+
+	segs := strings.Split(u.EscapedPath(), "/")
+	for i, seg := range segs {
+		kind, _, ok := strings.Cut(seg, ":")
+		if ok && containsFold(s.PathIDs, kind) {
+			segs[i] = kind + ":" + Redacted
+		}
+	}
+
+	out := strings.Join(segs, "/")
+	if len(q) > 0 {
+		out += "?" + q.Encode()
+	}
+	return out
+}
+
+// body scrubs JSON bodies field by field and form bodies by parameter.
+// Anything else is kept as is.
+func (s Scrubber) body(body []byte) string {
+	var v any
+	if err := json.Unmarshal(body, &v); err == nil {
+		data, _ := json.Marshal(s.value(v))
+		return string(data)
+	}
+
+	if form, err := url.ParseQuery(string(body)); err == nil && strings.Contains(string(body), "=") {
+		for k := range form {
+			if containsFold(s.QueryParams, k) {
+				form.Set(k, Redacted)
+			}
+		}
+		return form.Encode()
+	}
+
+	return string(body)
+}
+
+func (s Scrubber) value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, sub := range v {
+			if containsFold(s.JSONFields, k) {
+				if _, isObject := sub.(map[string]any); !isObject && sub != nil {
+					v[k] = Redacted
+					continue
+				}
+			}
+			v[k] = s.value(sub)
+		}
+	case []any:
+		for i := range v {
+			v[i] = s.value(v[i])
+		}
+	case string:
+		return s.learnURL(v)
+	}
+	return v
+}
+
+// learnURL scrubs str if it is a Learn API path or URL and returns anything
+// else unchanged.
+func (s Scrubber) learnURL(str string) string {
+	if !strings.Contains(str, apiPrefix) {
+		return str
+	}
+	u, err := url.Parse(str)
+	if err != nil || !strings.HasPrefix(u.Path, apiPrefix) {
+		return str
+	}
+
+	out := s.url(u)
+	if u.Host != "" {
+		out = u.Scheme + "://" + u.Host + out
+	}
+	return out
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// Recorder is an http.RoundTripper that passes calls on to a real transport
+// and keeps a scrubbed copy of each one.
+//
+//	rec := chawktest.NewRecorder(nil)
+//	client, _ := chawk.NewClient(id, secret, baseURL, chawk.WithTransport(rec))
+//	...
+//	rec.Save("testdata/copy_course.json")
+type Recorder struct {
+	Scrubber Scrubber
+
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder records calls made through next, or through
+// http.DefaultTransport when next is nil.
+func NewRecorder(next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{Scrubber: DefaultScrubber(), next: next}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		reqBody, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	in := Interaction{
+		Request: RecordedRequest{
+			Method: req.Method,
+			URL:    r.Scrubber.url(req.URL),
+			Header: r.Scrubber.header(req.Header),
+			Body:   r.Scrubber.body(reqBody),
+		},
+		Response: RecordedResponse{
+			Status: resp.StatusCode,
+			Header: r.Scrubber.header(resp.Header),
+			Body:   r.Scrubber.body(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, in)
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// Cassette returns a copy of what has been recorded so far.
+func (r *Recorder) Cassette() *Cassette {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return &Cassette{Interactions: append([]Interaction(nil), r.cassette.Interactions...)}
+}
+
+// Save writes everything recorded so far to path.
+func (r *Recorder) Save(path string) error {
+	return r.Cassette().Save(path)
+}
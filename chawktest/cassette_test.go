@@ -0,0 +1,319 @@
+package chawktest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+var update = flag.Bool("update", false, "record the cassettes in testdata again")
+
+func TestScrubberURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"/learn/api/public/v1/users/userName:jdoe", "/learn/api/public/v1/users/userName:REDACTED"},
+		{"/learn/api/public/v1/courses/courseId:BIO-101/users/userName:jdoe", "/learn/api/public/v1/courses/courseId:BIO-101/users/userName:REDACTED"},
+		{"/learn/api/public/v1/users/externalId:sis-4821/courses", "/learn/api/public/v1/users/externalId:REDACTED/courses"},
+		{"/learn/api/public/v1/users/_12_1", "/learn/api/public/v1/users/_12_1"},
+		{"/learn/api/public/v1/users?userName=jdoe&limit=5", "/learn/api/public/v1/users?limit=5&userName=REDACTED"},
+		{"/learn/api/public/v1/oauth2/token?code=abc&redirect_uri=x", "/learn/api/public/v1/oauth2/token?code=REDACTED&redirect_uri=REDACTED"},
+	}
+
+	s := DefaultScrubber()
+	for _, tt := range tests {
+		u, err := url.Parse(tt.in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := s.url(u); got != tt.want {
+			t.Errorf("url(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScrubberBody(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{
+			name: "user",
+			in:   `{"id":"_1_1","userName":"jdoe","externalId":"sis-1","name":{"given":"Jane","family":"Doe"},"contact":{"email":"j@example.edu"}}`,
+			want: `{"contact":{"email":"REDACTED"},"externalId":"REDACTED","id":"_1_1","name":{"family":"REDACTED","given":"REDACTED"},"userName":"REDACTED"}`,
+		},
+		{
+			name: "nested in a page",
+			in:   `{"results":[{"user":{"userName":"jdoe"},"courseRoleId":"Student"}]}`,
+			want: `{"results":[{"courseRoleId":"Student","user":{"userName":"REDACTED"}}]}`,
+		},
+		{
+			name: "next page",
+			in:   `{"results":[],"paging":{"nextPage":"/learn/api/public/v1/users?userName=jdoe&offset=2"}}`,
+			want: `{"paging":{"nextPage":"/learn/api/public/v1/users?offset=2\u0026userName=REDACTED"},"results":[]}`,
+		},
+		{
+			name: "next page by ID",
+			in:   `{"paging":{"nextPage":"/learn/api/public/v1/users/userName:jdoe/courses?offset=5"}}`,
+			want: `{"paging":{"nextPage":"/learn/api/public/v1/users/userName:REDACTED/courses?offset=5"}}`,
+		},
+		{
+			name: "full URL",
+			in:   `{"link":"https://learn.example.edu/learn/api/public/v1/users/externalId:sis-1"}`,
+			want: `{"link":"https://learn.example.edu/learn/api/public/v1/users/externalId:REDACTED"}`,
+		},
+		{name: "plain string", in: `{"description":"see /learn/api docs"}`, want: `{"description":"see /learn/api docs"}`},
+		{name: "token", in: `{"access_token":"secret","expires_in":3599}`, want: `{"access_token":"REDACTED","expires_in":3599}`},
+		{name: "form", in: `grant_type=authorization_code&code=abc`, want: `code=REDACTED&grant_type=authorization_code`},
+		{name: "other", in: `not json`, want: `not json`},
+	}
+
+	s := DefaultScrubber()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.body([]byte(tt.in)); got != tt.want {
+				t.Errorf("got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// courseCopyServer has a course with a few pages of users and columns.
+func courseCopyServer() *Server {
+	srv := NewServer()
+	srv.PageSize = 2
+	srv.TaskPolls = 2
+
+	srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology", TermID: "FA"})
+	for _, u := range []string{"jdoe", "asmith", "bchan", "cdiaz", "prof"} {
+		srv.AddUser(chawk.User{UserName: u, Name: chawk.Name{Given: u, Family: "Tester"}})
+		role := ""
+		if u == "prof" {
+			role = chawk.RoleInstructor
+		}
+		srv.Enroll("BIO-101", u, role)
+	}
+	for _, name := range []string{"Quiz 1", "Quiz 2", "Final"} {
+		srv.AddColumn("BIO-101", chawk.GradebookColumn{Name: name})
+	}
+	return srv
+}
+
+// courseCopyResult is what courseCopyCalls saw, minus anything scrubbed.
+type courseCopyResult struct {
+	Roles   []string
+	Columns []string
+	Task    string
+}
+
+// courseCopyCalls lists users and columns and copies the course, in the
+// order given.
+func courseCopyCalls(ctx context.Context, client *chawk.BlackboardClient, order ...string) (courseCopyResult, error) {
+	var res courseCopyResult
+	course := chawk.ByCourseID("BIO-101")
+
+	for _, call := range order {
+		switch call {
+		case "users":
+			users, err := client.Courses.GetUsers(ctx, course)
+			if err != nil {
+				return res, err
+			}
+			for _, u := range users {
+				res.Roles = append(res.Roles, u.CourseRoleID)
+			}
+		case "columns":
+			cols, err := client.Gradebook.GetColumns(ctx, course)
+			if err != nil {
+				return res, err
+			}
+			for _, c := range cols {
+				res.Columns = append(res.Columns, c.Name)
+			}
+		case "copy":
+			uri, err := client.Courses.CopyCourse(ctx, course, "BIO-101-COPY", chawk.FullCopy())
+			if err != nil {
+				return res, err
+			}
+			task, err := client.Courses.WaitForTask(ctx, uri, time.Millisecond)
+			if err != nil {
+				return res, err
+			}
+			res.Task = task.Status
+		}
+	}
+	return res, nil
+}
+
+var wantCourseCopy = courseCopyResult{
+	Roles:   []string{"Student", "Student", "Student", "Student", "Instructor"},
+	Columns: []string{"Quiz 1", "Quiz 2", "Final"},
+	Task:    chawk.TaskComplete,
+}
+
+// record runs courseCopyCalls against a fresh server and returns the cassette.
+func record(t *testing.T) *Cassette {
+	t.Helper()
+	srv := courseCopyServer()
+	defer srv.Close()
+
+	rec := NewRecorder(srv.Client().Transport)
+	client, err := chawk.NewClient(srv.ClientID, srv.ClientSecret, srv.URL,
+		chawk.WithTokenStore(chawk.NewMemoryTokenStore()), chawk.WithTransport(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := courseCopyCalls(context.Background(), client, "users", "columns", "copy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, wantCourseCopy) {
+		t.Fatalf("recording: got %+v, want %+v", got, wantCourseCopy)
+	}
+	return rec.Cassette()
+}
+
+// replay runs courseCopyCalls against c, with nothing listening at the
+// base URL.
+func replay(t *testing.T, c *Cassette, mode MatchMode, order ...string) {
+	t.Helper()
+	rp := NewReplayer(c, mode)
+	client, err := chawk.NewClient("key", "secret", "https://learn.example.edu",
+		chawk.WithTokenStore(chawk.NewMemoryTokenStore()), chawk.WithTransport(rp))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := courseCopyCalls(context.Background(), client, order...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, wantCourseCopy) {
+		t.Errorf("got %+v, want %+v", got, wantCourseCopy)
+	}
+	if unused := rp.Unused(); len(unused) > 0 {
+		t.Errorf("%d interactions never replayed, first %s %s", len(unused), unused[0].Request.Method, unused[0].Request.URL)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	c := record(t)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"jdoe", "asmith", "chawktest-secret", "Tester", "Bearer "} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette has %q in it", secret)
+		}
+	}
+
+	loaded, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("strict", func(t *testing.T) {
+		replay(t, loaded, MatchStrict, "users", "columns", "copy")
+	})
+	t.Run("strict out of order", func(t *testing.T) {
+		rp := NewReplayer(loaded, MatchStrict)
+		client, err := chawk.NewClient("key", "secret", "https://learn.example.edu",
+			chawk.WithTokenStore(chawk.NewMemoryTokenStore()), chawk.WithTransport(rp),
+			chawk.WithRetryPolicy(chawk.RetryPolicy{}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The token call matches; listing columns before users doesn't.
+		if _, err := courseCopyCalls(context.Background(), client, "columns"); !errors.Is(err, ErrNoInteraction) {
+			t.Errorf("err = %v, want ErrNoInteraction", err)
+		}
+	})
+	t.Run("lenient", func(t *testing.T) {
+		replay(t, loaded, MatchLenient, "copy", "columns", "users")
+	})
+}
+
+// TestCassetteCourseCopy replays testdata/course_copy.json, which is kept
+// in the repo as an example. Run with -update to record it again.
+func TestCassetteCourseCopy(t *testing.T) {
+	path := filepath.Join("testdata", "course_copy.json")
+	if *update {
+		if err := record(t).Save(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	c, err := LoadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, mode := range []MatchMode{MatchStrict, MatchLenient} {
+		replay(t, c, mode, "users", "columns", "copy")
+	}
+}
+
+// A list filtered by userName carries the filter in every nextPage. None of
+// it may reach the cassette, and replay still has to follow the pages.
+func TestRecordReplayFilteredPages(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	for _, name := range []string{"jdoe1", "jdoe2", "jdoe3", "asmith"} {
+		srv.AddUser(chawk.User{UserName: name})
+	}
+
+	list := func(client *chawk.BlackboardClient) int {
+		t.Helper()
+		n := 0
+		for _, err := range client.Users.List(context.Background(), chawk.UserQuery{UserName: "jdoe", PageSize: 1}) {
+			if err != nil {
+				t.Fatal(err)
+			}
+			n++
+		}
+		return n
+	}
+
+	rec := NewRecorder(srv.Client().Transport)
+	client, err := chawk.NewClient(srv.ClientID, srv.ClientSecret, srv.URL,
+		chawk.WithTokenStore(chawk.NewMemoryTokenStore()), chawk.WithTransport(rec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := list(client); n != 3 {
+		t.Fatalf("recording: got %d users, want 3", n)
+	}
+
+	c := rec.Cassette()
+	saved, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(saved), "jdoe") {
+		t.Errorf("cassette still has the userName: %s", saved)
+	}
+
+	client, err = chawk.NewClient("key", "secret", "https://learn.example.edu",
+		chawk.WithTokenStore(chawk.NewMemoryTokenStore()), chawk.WithTransport(NewReplayer(c, MatchStrict)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := list(client); n != 3 {
+		t.Errorf("replay: got %d users, want 3", n)
+	}
+}
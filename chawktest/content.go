@@ -0,0 +1,148 @@
+package chawktest
+
+import (
+	"net/http"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func (s *Server) routeGradebook(w http.ResponseWriter, r *http.Request, c *chawk.Course, segs []string) {
+	if len(segs) == 0 || segs[0] != "columns" {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	switch {
+	case len(segs) == 1 && r.Method == http.MethodGet:
+		var items []map[string]any
+		for _, col := range s.columns[c.ID] {
+			items = append(items, toMap(*col))
+		}
+		s.writePage(w, r, items)
+
+	case len(segs) == 1 && r.Method == http.MethodPost:
+		var col chawk.GradebookColumn
+		if err := readJSON(r, &col); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if col.Name == "" {
+			writeError(w, http.StatusBadRequest, "name is required")
+			return
+		}
+		for _, existing := range s.columns[c.ID] {
+			if existing.Name == col.Name {
+				writeError(w, http.StatusConflict, "a column with that name already exists")
+				return
+			}
+		}
+
+		col.ID = s.nextID()
+		col.Created = now()
+		s.columns[c.ID] = append(s.columns[c.ID], &col)
+		writeObject(w, r, http.StatusCreated, toMap(col))
+
+	case len(segs) == 2 && r.Method == http.MethodGet:
+		for _, col := range s.columns[c.ID] {
+			if col.ID == segs[1] || col.ExternalID != "" && "externalId:"+col.ExternalID == segs[1] {
+				writeObject(w, r, http.StatusOK, toMap(*col))
+				return
+			}
+		}
+		writeError(w, http.StatusNotFound, "column not found")
+
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	}
+}
+
+func (s *Server) routeAnnouncements(w http.ResponseWriter, r *http.Request, c *chawk.Course, segs []string) {
+	if len(segs) == 0 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		var items []map[string]any
+		for _, a := range s.announcements[c.ID] {
+			items = append(items, toMap(*a))
+		}
+		s.writePage(w, r, items)
+		return
+	}
+
+	list := s.announcements[c.ID]
+	for i, a := range list {
+		if a.ID != segs[0] {
+			continue
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeObject(w, r, http.StatusOK, toMap(*a))
+		case http.MethodDelete:
+			s.announcements[c.ID] = append(list[:i], list[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "announcement not found")
+}
+
+func (s *Server) routeDiscussions(w http.ResponseWriter, r *http.Request, c *chawk.Course, segs []string) {
+	if len(segs) == 0 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		var items []map[string]any
+		for _, f := range s.forums[c.ID] {
+			items = append(items, toMap(f))
+		}
+		s.writePage(w, r, items)
+		return
+	}
+
+	f := s.findForum(c.ID, segs[0])
+	if f == nil || len(segs) < 2 || segs[1] != "messages" {
+		writeError(w, http.StatusNotFound, "discussion not found")
+		return
+	}
+
+	if len(segs) == 2 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+
+		var items []map[string]any
+		for _, m := range f.Messages {
+			items = append(items, toMap(m))
+		}
+		s.writePage(w, r, items)
+		return
+	}
+
+	for i, m := range f.Messages {
+		if m.ID != segs[2] {
+			continue
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeObject(w, r, http.StatusOK, toMap(m))
+		case http.MethodDelete:
+			f.Messages = append(f.Messages[:i], f.Messages[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "message not found")
+}
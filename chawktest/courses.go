@@ -0,0 +1,531 @@
+package chawktest
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func (s *Server) routeCourses(w http.ResponseWriter, r *http.Request, segs []string) {
+	if len(segs) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			s.listCourses(w, r)
+		case http.MethodPost:
+			s.createCourse(w, r)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	c := s.findCourse(segs[0])
+	if c == nil {
+		writeError(w, http.StatusNotFound, "course not found")
+		return
+	}
+
+	rest := segs[1:]
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			writeObject(w, r, http.StatusOK, toMap(*c))
+		case http.MethodPatch:
+			s.patchCourse(w, r, c)
+		case http.MethodDelete:
+			s.deleteCourse(w, c)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+		return
+	}
+
+	switch rest[0] {
+	case "copy":
+		s.copyCourse(w, r, c)
+	case "tasks":
+		s.routeTasks(w, r, c, rest[1:])
+	case "children":
+		s.addChild(w, r, c, rest[1:])
+	case "users":
+		s.routeMemberships(w, r, c, rest[1:])
+	case "gradebook":
+		s.routeGradebook(w, r, c, rest[1:])
+	case "announcements":
+		s.routeAnnouncements(w, r, c, rest[1:])
+	case "discussions":
+		s.routeDiscussions(w, r, c, rest[1:])
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	}
+}
+
+func (s *Server) listCourses(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkDates(q, "created", "modified"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var courses []*chawk.Course
+	for _, c := range s.courses {
+		ok, err := matchCourse(c, q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if ok {
+			courses = append(courses, c)
+		}
+	}
+
+	if sortBy := q.Get("sort"); sortBy != "" {
+		field, desc := strings.CutSuffix(sortBy, "(desc)")
+		slices.SortStableFunc(courses, func(a, b *chawk.Course) int {
+			c := cmp.Compare(courseSortKey(a, field), courseSortKey(b, field))
+			if desc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	var items []map[string]any
+	for _, c := range courses {
+		items = append(items, toMap(*c))
+	}
+	s.writePage(w, r, items)
+}
+
+// matchCourse applies the filters Learn supports on GET /courses.
+func matchCourse(c *chawk.Course, q url.Values) (bool, error) {
+	contains := map[string]string{
+		"courseId":   c.CourseID,
+		"name":       c.Name,
+		"externalId": c.ExternalID,
+	}
+	for param, value := range contains {
+		if want := q.Get(param); want != "" && !strings.Contains(strings.ToLower(value), strings.ToLower(want)) {
+			return false, nil
+		}
+	}
+
+	exact := map[string]string{
+		"termId":                 c.TermID,
+		"dataSourceId":           c.DataSourceID,
+		"availability.available": c.Availability.Available,
+		"ultraStatus":            c.UltraStatus,
+	}
+	for param, value := range exact {
+		if want := q.Get(param); want != "" && value != want {
+			return false, nil
+		}
+	}
+
+	if want := q.Get("organization"); want != "" {
+		org, err := strconv.ParseBool(want)
+		if err != nil {
+			return false, fmt.Errorf("invalid organization: %w", err)
+		}
+		if c.Organization != org {
+			return false, nil
+		}
+	}
+
+	for param, got := range map[string]*time.Time{"created": c.Created, "modified": c.Modified} {
+		if ok, err := matchDate(q, param, got); !ok || err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+func courseSortKey(c *chawk.Course, field string) string {
+	switch field {
+	case "courseId":
+		return c.CourseID
+	case "name":
+		return c.Name
+	case "externalId":
+		return c.ExternalID
+	case "termId":
+		return c.TermID
+	case "created":
+		if c.Created != nil {
+			return c.Created.UTC().Format(sortableTime)
+		}
+	case "modified":
+		if c.Modified != nil {
+			return c.Modified.UTC().Format(sortableTime)
+		}
+	}
+	return ""
+}
+
+func (s *Server) createCourse(w http.ResponseWriter, r *http.Request) {
+	var c chawk.Course
+	if err := readJSON(r, &c); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if c.CourseID == "" || c.Name == "" {
+		writeError(w, http.StatusBadRequest, "courseId and name are required")
+		return
+	}
+	if s.findCourse("courseId:"+c.CourseID) != nil {
+		writeError(w, http.StatusConflict, "a course with that courseId already exists")
+		return
+	}
+
+	c.ID, c.UUID, c.Created, c.Modified = "", "", nil, nil
+
+	writeObject(w, r, http.StatusCreated, toMap(*s.insertCourse(c)))
+}
+
+func (s *Server) patchCourse(w http.ResponseWriter, r *http.Request, c *chawk.Course) {
+	var patch map[string]any
+	if err := readJSON(r, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	merged := toMap(*c)
+	mergePatch(merged, patch)
+
+	var updated chawk.Course
+	if err := fromMap(merged, &updated); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if other := s.findCourse("courseId:" + updated.CourseID); other != nil && other != c {
+		writeError(w, http.StatusConflict, "a course with that courseId already exists")
+		return
+	}
+
+	updated.ID, updated.UUID, updated.Created = c.ID, c.UUID, c.Created
+	updated.Modified = chawk.ToPtr(time.Now().UTC())
+	*c = updated
+
+	writeObject(w, r, http.StatusOK, toMap(*c))
+}
+
+func (s *Server) deleteCourse(w http.ResponseWriter, c *chawk.Course) {
+	for i, existing := range s.courses {
+		if existing == c {
+			s.courses = append(s.courses[:i], s.courses[i+1:]...)
+			break
+		}
+	}
+
+	kept := s.memberships[:0]
+	for _, m := range s.memberships {
+		if m.CourseID != c.ID {
+			kept = append(kept, m)
+		}
+	}
+	s.memberships = kept
+
+	delete(s.columns, c.ID)
+	delete(s.announcements, c.ID)
+	delete(s.forums, c.ID)
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// copyCourse copies the gradebook and announcements of src into the target
+// course, creating it first when it doesn't exist. The copy finishes right
+// away; the task it returns is already complete.
+func (s *Server) copyCourse(w http.ResponseWriter, r *http.Request, src *chawk.Course) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var body struct {
+		TargetCourse struct {
+			ID       string `json:"id"`
+			CourseID string `json:"courseId"`
+		} `json:"targetCourse"`
+		Copy map[string]any `json:"copy"`
+	}
+	if err := readJSON(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var target *chawk.Course
+	switch {
+	case body.TargetCourse.ID != "":
+		target = s.findCourse(body.TargetCourse.ID)
+		if target == nil {
+			writeError(w, http.StatusNotFound, "target course not found")
+			return
+		}
+	case body.TargetCourse.CourseID != "":
+		target = s.findCourse("courseId:" + body.TargetCourse.CourseID)
+		if target == nil {
+			cp := *src
+			cp.ID, cp.UUID, cp.Created, cp.Modified = "", "", nil, nil
+			cp.CourseID = body.TargetCourse.CourseID
+			cp.ExternalID = body.TargetCourse.CourseID
+			cp.CopyHistory = []chawk.CopyHistory{{UUID: src.UUID}}
+			target = s.insertCourse(cp)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "targetCourse is required")
+		return
+	}
+
+	copies := func(key string) bool {
+		if body.Copy == nil {
+			return true
+		}
+		v, ok := body.Copy[key]
+		return ok && v != false
+	}
+
+	if copies("gradebook") {
+		for _, col := range s.columns[src.ID] {
+			cp := *col
+			cp.ID = s.nextID()
+			s.columns[target.ID] = append(s.columns[target.ID], &cp)
+		}
+	}
+	if copies("announcements") {
+		for _, a := range s.announcements[src.ID] {
+			cp := *a
+			cp.ID = s.nextID()
+			s.announcements[target.ID] = append(s.announcements[target.ID], &cp)
+		}
+	}
+
+	// The copy itself is already done; the task only pretends to take a while.
+	task := &Task{ID: s.nextID(), Status: "Queued", Queued: now(), courseID: target.ID, seq: s.seq}
+	s.tasks[task.ID] = task
+	s.advanceTask(task)
+
+	w.Header().Set("Location", apiPrefix+"v1/courses/"+target.ID+"/tasks/"+task.ID)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (s *Server) routeTasks(w http.ResponseWriter, r *http.Request, c *chawk.Course, segs []string) {
+	if len(segs) > 1 || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	if len(segs) == 0 {
+		var tasks []*Task
+		for _, task := range s.tasks {
+			if task.courseID == c.ID {
+				tasks = append(tasks, task)
+			}
+		}
+		slices.SortFunc(tasks, func(a, b *Task) int { return cmp.Compare(a.seq, b.seq) })
+
+		var items []map[string]any
+		for _, task := range tasks {
+			items = append(items, toMap(task))
+		}
+		s.writePage(w, r, items)
+		return
+	}
+
+	task, ok := s.tasks[segs[0]]
+	if !ok || task.courseID != c.ID {
+		writeError(w, http.StatusNotFound, "task not found")
+		return
+	}
+	writeObject(w, r, http.StatusOK, toMap(task))
+
+	task.polls++
+	s.advanceTask(task)
+}
+
+// advanceTask moves task along according to TaskPolls.
+func (s *Server) advanceTask(task *Task) {
+	if task.Status == "Complete" {
+		return
+	}
+	if task.polls >= s.TaskPolls {
+		task.Status, task.PercentComplete = "Complete", 100
+		if task.Started == "" {
+			task.Started = now()
+		}
+		task.Completed = now()
+		return
+	}
+	if task.polls > 0 {
+		task.Status = "Running"
+		task.PercentComplete = task.polls * 100 / s.TaskPolls
+		if task.Started == "" {
+			task.Started = now()
+		}
+	}
+}
+
+func (s *Server) addChild(w http.ResponseWriter, r *http.Request, parent *chawk.Course, segs []string) {
+	if len(segs) != 1 || r.Method != http.MethodPut {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	child := s.findCourse(segs[0])
+	if child == nil {
+		writeError(w, http.StatusNotFound, "child course not found")
+		return
+	}
+	if child.ParentID != "" {
+		writeError(w, http.StatusConflict, "course is already a child")
+		return
+	}
+
+	child.ParentID = parent.ID
+	parent.HasChildren = true
+	w.WriteHeader(http.StatusCreated)
+}
+
+func matchMembership(m *Membership, q url.Values) (bool, error) {
+	exact := map[string]string{
+		"role":                   m.CourseRoleID,
+		"availability.available": m.Available,
+		"dataSourceId":           m.DataSourceID,
+	}
+	for param, value := range exact {
+		if want := q.Get(param); want != "" && value != want {
+			return false, nil
+		}
+	}
+
+	dates := map[string]*time.Time{
+		"created":      &m.Created,
+		"modified":     &m.Modified,
+		"lastAccessed": m.LastAccessed,
+	}
+	for param, got := range dates {
+		ok, err := matchDate(q, param, got)
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (s *Server) routeMemberships(w http.ResponseWriter, r *http.Request, c *chawk.Course, segs []string) {
+	if len(segs) == 0 {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		if err := checkDates(r.URL.Query(), "created", "modified", "lastAccessed"); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		var items []map[string]any
+		for _, m := range s.memberships {
+			if m.CourseID != c.ID {
+				continue
+			}
+			ok, err := matchMembership(m, r.URL.Query())
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err.Error())
+				return
+			}
+			if ok {
+				items = append(items, s.membershipMap(r, m))
+			}
+		}
+		s.writePage(w, r, items)
+		return
+	}
+
+	u := s.findUser(segs[0])
+	if u == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+	m := s.findMembership(c.ID, u.ID)
+
+	switch r.Method {
+	case http.MethodGet:
+		if m == nil {
+			writeError(w, http.StatusNotFound, "membership not found")
+			return
+		}
+		writeObject(w, r, http.StatusOK, s.membershipMap(r, m))
+
+	case http.MethodPut, http.MethodPatch:
+		var req chawk.EnrollmentRequest
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+
+		status := http.StatusOK
+		if r.Method == http.MethodPut {
+			if m != nil {
+				writeError(w, http.StatusConflict, "user is already enrolled")
+				return
+			}
+			m = &Membership{
+				ID:           s.nextID(),
+				UserID:       u.ID,
+				CourseID:     c.ID,
+				CourseRoleID: chawk.RoleStudent,
+				Available:    chawk.AvailabilityYes,
+				Created:      time.Now(),
+			}
+			s.memberships = append(s.memberships, m)
+			status = http.StatusCreated
+		} else if m == nil {
+			writeError(w, http.StatusNotFound, "membership not found")
+			return
+		}
+
+		if req.CourseRoleID != nil && *req.CourseRoleID != "" {
+			m.CourseRoleID = *req.CourseRoleID
+		}
+		if req.Availability != nil && req.Availability.Available != nil && *req.Availability.Available != "" {
+			m.Available = *req.Availability.Available
+		}
+		if req.ChildCourseID != nil {
+			m.ChildCourseID = *req.ChildCourseID
+		}
+		if req.DataSourceID != nil {
+			m.DataSourceID = *req.DataSourceID
+		}
+		if req.DisplayOrder != nil {
+			m.DisplayOrder = *req.DisplayOrder
+		}
+		m.Modified = time.Now()
+
+		writeObject(w, r, status, s.membershipMap(r, m))
+
+	case http.MethodDelete:
+		if m == nil {
+			writeError(w, http.StatusNotFound, "membership not found")
+			return
+		}
+		for i, existing := range s.memberships {
+			if existing == m {
+				s.memberships = append(s.memberships[:i], s.memberships[i+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
@@ -0,0 +1,287 @@
+package chawktest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+// AddUser stores u, filling in the ID, UUID and availability if unset,
+// and returns what was stored.
+func (s *Server) AddUser(u chawk.User) chawk.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.insertUser(u)
+}
+
+// AddCourse stores c, filling in the ID, UUID and created date if unset.
+func (s *Server) AddCourse(c chawk.Course) chawk.Course {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.insertCourse(c)
+}
+
+// Enroll adds userName to courseID with role. An empty role means Student.
+func (s *Server) Enroll(courseID, userName, role string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	u := s.findUser("userName:" + userName)
+	if c == nil || u == nil {
+		return fmt.Errorf("no course %q or user %q", courseID, userName)
+	}
+	if s.findMembership(c.ID, u.ID) != nil {
+		return fmt.Errorf("%q is already in %q", userName, courseID)
+	}
+
+	if role == "" {
+		role = chawk.RoleStudent
+	}
+
+	s.memberships = append(s.memberships, &Membership{
+		ID:           s.nextID(),
+		UserID:       u.ID,
+		CourseID:     c.ID,
+		CourseRoleID: role,
+		Available:    chawk.AvailabilityYes,
+		Created:      time.Now(),
+		Modified:     time.Now(),
+	})
+	return nil
+}
+
+// AddColumn adds a gradebook column to courseID.
+func (s *Server) AddColumn(courseID string, col chawk.GradebookColumn) (chawk.GradebookColumn, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	if c == nil {
+		return col, fmt.Errorf("no course %q", courseID)
+	}
+
+	col.ID = s.nextID()
+	col.Created = now()
+	s.columns[c.ID] = append(s.columns[c.ID], &col)
+	return col, nil
+}
+
+// AddAnnouncement adds an announcement to courseID.
+func (s *Server) AddAnnouncement(courseID string, a chawk.Announcement) (chawk.Announcement, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	if c == nil {
+		return a, fmt.Errorf("no course %q", courseID)
+	}
+
+	a.ID = s.nextID()
+	a.Created = now()
+	s.announcements[c.ID] = append(s.announcements[c.ID], &a)
+	return a, nil
+}
+
+// AddDiscussion adds a forum to courseID and returns its ID.
+func (s *Server) AddDiscussion(courseID, title string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	if c == nil {
+		return "", fmt.Errorf("no course %q", courseID)
+	}
+
+	f := &forum{ID: s.nextID(), Title: title}
+	s.forums[c.ID] = append(s.forums[c.ID], f)
+	return f.ID, nil
+}
+
+// AddMessage posts body to a forum as userName and returns the message ID.
+func (s *Server) AddMessage(courseID, forumID, userName, body string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	u := s.findUser("userName:" + userName)
+	if c == nil || u == nil {
+		return "", fmt.Errorf("no course %q or user %q", courseID, userName)
+	}
+
+	f := s.findForum(c.ID, forumID)
+	if f == nil {
+		return "", fmt.Errorf("no forum %q in %q", forumID, courseID)
+	}
+
+	m := &message{ID: s.nextID(), UserID: u.ID, Body: body}
+	f.Messages = append(f.Messages, m)
+	return m.ID, nil
+}
+
+// User returns the stored user with userName.
+func (s *Server) User(userName string) (chawk.User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUser("userName:" + userName)
+	if u == nil {
+		return chawk.User{}, false
+	}
+	return *u, true
+}
+
+// Course returns the stored course with courseID.
+func (s *Server) Course(courseID string) (chawk.Course, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	if c == nil {
+		return chawk.Course{}, false
+	}
+	return *c, true
+}
+
+// Membership returns userName's membership in courseID.
+func (s *Server) Membership(courseID, userName string) (Membership, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	u := s.findUser("userName:" + userName)
+	if c == nil || u == nil {
+		return Membership{}, false
+	}
+
+	m := s.findMembership(c.ID, u.ID)
+	if m == nil {
+		return Membership{}, false
+	}
+	return *m, true
+}
+
+// Touch records that userName opened courseID at t.
+func (s *Server) Touch(courseID, userName string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	u := s.findUser("userName:" + userName)
+	if c == nil || u == nil {
+		return fmt.Errorf("no course %q or user %q", courseID, userName)
+	}
+	m := s.findMembership(c.ID, u.ID)
+	if m == nil {
+		return fmt.Errorf("%q isn't in %q", userName, courseID)
+	}
+
+	m.LastAccessed = &t
+	return nil
+}
+
+// Columns returns the gradebook columns of courseID.
+func (s *Server) Columns(courseID string) []chawk.GradebookColumn {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c := s.findCourse("courseId:" + courseID)
+	if c == nil {
+		return nil
+	}
+
+	var out []chawk.GradebookColumn
+	for _, col := range s.columns[c.ID] {
+		out = append(out, *col)
+	}
+	return out
+}
+
+func (s *Server) insertUser(u chawk.User) *chawk.User {
+	if u.ID == "" {
+		u.ID = s.nextID()
+	}
+	if u.UUID == "" {
+		u.UUID = newSecret()
+	}
+	if u.Availability.Available == "" {
+		u.Availability.Available = chawk.AvailabilityYes
+	}
+	if u.Created == nil {
+		u.Created = chawk.ToPtr(time.Now().UTC())
+	}
+
+	s.users = append(s.users, &u)
+	return &u
+}
+
+func (s *Server) insertCourse(c chawk.Course) *chawk.Course {
+	if c.ID == "" {
+		c.ID = s.nextID()
+	}
+	if c.UUID == "" {
+		c.UUID = newSecret()
+	}
+	if c.Availability.Available == "" {
+		c.Availability.Available = chawk.AvailabilityYes
+	}
+	if c.Created == nil {
+		c.Created = chawk.ToPtr(time.Now().UTC())
+	}
+
+	s.courses = append(s.courses, &c)
+	return &c
+}
+
+// findUser resolves any of the ID forms Learn takes in a path: the primary
+// ID, userName:, externalId: or uuid:.
+func (s *Server) findUser(ref string) *chawk.User {
+	kind, value, prefixed := strings.Cut(ref, ":")
+
+	for _, u := range s.users {
+		switch {
+		case !prefixed && u.ID == ref,
+			kind == "userName" && u.UserName == value,
+			kind == "externalId" && u.ExternalID == value,
+			kind == "uuid" && u.UUID == value:
+			return u
+		}
+	}
+	return nil
+}
+
+// findCourse resolves the primary ID, courseId:, externalId: or uuid:.
+func (s *Server) findCourse(ref string) *chawk.Course {
+	kind, value, prefixed := strings.Cut(ref, ":")
+
+	for _, c := range s.courses {
+		switch {
+		case !prefixed && c.ID == ref,
+			kind == "courseId" && c.CourseID == value,
+			kind == "externalId" && c.ExternalID == value,
+			kind == "uuid" && c.UUID == value:
+			return c
+		}
+	}
+	return nil
+}
+
+func (s *Server) findMembership(courseID, userID string) *Membership {
+	for _, m := range s.memberships {
+		if m.CourseID == courseID && m.UserID == userID {
+			return m
+		}
+	}
+	return nil
+}
+
+func (s *Server) findForum(courseID, forumID string) *forum {
+	for _, f := range s.forums[courseID] {
+		if f.ID == forumID {
+			return f
+		}
+	}
+	return nil
+}
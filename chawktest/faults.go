@@ -0,0 +1,64 @@
+package chawktest
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Fault makes matching requests fail. Faults are checked before anything
+// else, so they can also break the token request.
+type Fault struct {
+	Method string // "" matches any method
+	Path   string // matches when the request path contains it, "" matches any
+	Status int
+	Body   string      // defaults to a Blackboard style error body
+	Header http.Header // extra response headers, e.g. Retry-After
+	Times  int         // how many requests to fail, 0 means all of them
+
+	hits int
+}
+
+// InjectFault adds a fault. Faults are tried in the order they were added.
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes every fault.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+func (s *Server) applyFault(w http.ResponseWriter, r *http.Request) bool {
+	for _, f := range s.faults {
+		if f.Method != "" && !strings.EqualFold(f.Method, r.Method) {
+			continue
+		}
+		if f.Path != "" && !strings.Contains(r.URL.Path, f.Path) {
+			continue
+		}
+		if f.Times > 0 && f.hits >= f.Times {
+			continue
+		}
+
+		f.hits++
+		for k, vs := range f.Header {
+			for _, v := range vs {
+				w.Header().Add(k, v)
+			}
+		}
+
+		if f.Body == "" {
+			writeError(w, f.Status, "injected fault")
+		} else {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(f.Status)
+			w.Write([]byte(f.Body))
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package chawktest
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// observerLink is one observer following one observee.
+type observerLink struct {
+	observerID string
+	observeeID string
+}
+
+// Observe makes observer an observer of observee, both by userName.
+func (s *Server) Observe(observer, observee string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.findUser("userName:" + observer)
+	e := s.findUser("userName:" + observee)
+	if o == nil || e == nil {
+		return fmt.Errorf("no user %q or %q", observer, observee)
+	}
+
+	link := observerLink{observerID: o.ID, observeeID: e.ID}
+	if !slices.Contains(s.observers, link) {
+		s.observers = append(s.observers, link)
+	}
+	return nil
+}
+
+// routeObservers serves /users/{id}/observers[/{observerId}] and
+// /users/{id}/observees.
+func (s *Server) routeObservers(w http.ResponseWriter, r *http.Request, segs []string) {
+	u := s.findUser(segs[0])
+	if u == nil {
+		writeError(w, http.StatusNotFound, "user not found")
+		return
+	}
+
+	switch {
+	case len(segs) == 2 && segs[1] == "observers" && r.Method == http.MethodGet:
+		var items []map[string]any
+		for _, l := range s.observers {
+			if l.observeeID == u.ID {
+				items = append(items, map[string]any{"observerId": l.observerID})
+			}
+		}
+		s.writePage(w, r, items)
+	case len(segs) == 2 && segs[1] == "observees" && r.Method == http.MethodGet:
+		var items []map[string]any
+		for _, l := range s.observers {
+			if l.observerID == u.ID {
+				items = append(items, map[string]any{"observeeId": l.observeeID})
+			}
+		}
+		s.writePage(w, r, items)
+	case len(segs) == 3 && segs[1] == "observers":
+		o := s.findUser(segs[2])
+		if o == nil {
+			writeError(w, http.StatusNotFound, "observer not found")
+			return
+		}
+		link := observerLink{observerID: o.ID, observeeID: u.ID}
+		i := slices.Index(s.observers, link)
+
+		switch r.Method {
+		case http.MethodPut:
+			if o.ID == u.ID {
+				writeError(w, http.StatusBadRequest, "a user can't observe themselves")
+				return
+			}
+			if i >= 0 {
+				writeError(w, http.StatusConflict, "already an observer of this user")
+				return
+			}
+			s.observers = append(s.observers, link)
+			writeJSON(w, http.StatusCreated, map[string]any{"observerId": o.ID})
+		case http.MethodDelete:
+			if i < 0 {
+				writeError(w, http.StatusNotFound, "not an observer of this user")
+				return
+			}
+			s.observers = slices.Delete(s.observers, i, i+1)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	}
+}
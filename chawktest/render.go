@@ -0,0 +1,142 @@
+package chawktest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// toMap turns one of the chawk types into its JSON object form, which is
+// what paging, fields and expand work on.
+func toMap(v any) map[string]any {
+	data, _ := json.Marshal(v)
+	m := map[string]any{}
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// fromMap is the reverse of toMap.
+func fromMap(m map[string]any, v any) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// mergePatch applies a PATCH body to an object: keys set to null are
+// removed, nested objects are merged, everything else is replaced.
+func mergePatch(dst, patch map[string]any) {
+	for k, v := range patch {
+		if v == nil {
+			delete(dst, k)
+			continue
+		}
+
+		if sub, ok := v.(map[string]any); ok {
+			existing, ok := dst[k].(map[string]any)
+			if !ok {
+				existing = map[string]any{}
+			}
+			mergePatch(existing, sub)
+			dst[k] = existing
+			continue
+		}
+
+		dst[k] = v
+	}
+}
+
+// expands reports whether the request asked to expand name.
+func expands(r *http.Request, name string) bool {
+	for _, e := range strings.Split(r.URL.Query().Get("expand"), ",") {
+		if strings.TrimSpace(e) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// project keeps only the dotted field paths asked for in fields.
+func project(m map[string]any, fields []string) map[string]any {
+	out := map[string]any{}
+	for _, f := range fields {
+		copyPath(out, m, strings.Split(strings.TrimSpace(f), "."))
+	}
+	return out
+}
+
+func copyPath(dst, src map[string]any, path []string) {
+	v, ok := src[path[0]]
+	if !ok {
+		return
+	}
+
+	if len(path) == 1 {
+		dst[path[0]] = v
+		return
+	}
+
+	sub, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	next, ok := dst[path[0]].(map[string]any)
+	if !ok {
+		next = map[string]any{}
+		dst[path[0]] = next
+	}
+	copyPath(next, sub, path[1:])
+}
+
+// render applies fields to a single object.
+func render(r *http.Request, m map[string]any) map[string]any {
+	if fields := r.URL.Query().Get("fields"); fields != "" {
+		return project(m, strings.Split(fields, ","))
+	}
+	return m
+}
+
+// writePage writes one page of items, using the offset and limit query
+// parameters, with a nextPage link when there is more.
+func (s *Server) writePage(w http.ResponseWriter, r *http.Request, items []map[string]any) {
+	q := r.URL.Query()
+
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	limit, err := strconv.Atoi(q.Get("limit"))
+	if err != nil || limit <= 0 {
+		limit = s.PageSize
+	}
+
+	offset = min(max(offset, 0), len(items))
+	end := min(offset+limit, len(items))
+
+	results := make([]map[string]any, 0, end-offset)
+	for _, item := range items[offset:end] {
+		results = append(results, render(r, item))
+	}
+
+	page := map[string]any{"results": results}
+
+	if end < len(items) {
+		next := url.Values{}
+		for k, v := range q {
+			next[k] = slices.Clone(v)
+		}
+		next.Set("offset", strconv.Itoa(end))
+		if q.Get("limit") != "" {
+			next.Set("limit", strconv.Itoa(limit))
+		}
+		page["paging"] = map[string]any{"nextPage": r.URL.EscapedPath() + "?" + next.Encode()}
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// writeObject writes a single object, applying fields.
+func writeObject(w http.ResponseWriter, r *http.Request, status int, m map[string]any) {
+	writeJSON(w, status, render(r, m))
+}
@@ -0,0 +1,179 @@
+package chawktest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ErrNoInteraction is returned by a Replayer when a request has no match in
+// the cassette.
+var ErrNoInteraction = errors.New("no matching interaction in cassette")
+
+// MatchMode is how a Replayer pairs requests with recorded interactions.
+type MatchMode int
+
+const (
+	// MatchStrict replays interactions in recorded order. Each request must
+	// have the same method, path, query and (scrubbed) body as the next one.
+	MatchStrict MatchMode = iota
+
+	// MatchLenient takes the first unused interaction with the same method,
+	// path and query parameters, in any order, and ignores the body.
+	MatchLenient
+)
+
+// Replayer is an http.RoundTripper that answers from a cassette and never
+// touches the network.
+//
+//	rp, err := chawktest.LoadReplayer("testdata/copy_course.json", chawktest.MatchStrict)
+//	client, _ := chawk.NewClient("key", "secret", "https://learn.example.edu",
+//		chawk.WithTransport(rp), chawk.WithTokenStore(chawk.NewMemoryTokenStore()))
+type Replayer struct {
+	Scrubber Scrubber
+
+	cassette *Cassette
+	mode     MatchMode
+
+	mu   sync.Mutex
+	used []bool
+	pos  int
+}
+
+// NewReplayer replays c. Requests are scrubbed with DefaultScrubber before
+// they are compared, like they were when recorded.
+func NewReplayer(c *Cassette, mode MatchMode) *Replayer {
+	return &Replayer{
+		Scrubber: DefaultScrubber(),
+		cassette: c,
+		mode:     mode,
+		used:     make([]bool, len(c.Interactions)),
+	}
+}
+
+// LoadReplayer loads the cassette at path and replays it.
+func LoadReplayer(path string, mode MatchMode) (*Replayer, error) {
+	c, err := LoadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewReplayer(c, mode), nil
+}
+
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	got := RecordedRequest{
+		Method: req.Method,
+		URL:    r.Scrubber.url(req.URL),
+		Body:   r.Scrubber.body(body),
+	}
+
+	r.mu.Lock()
+	in, err := r.match(got)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	header := in.Response.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", in.Response.Status, http.StatusText(in.Response.Status)),
+		StatusCode:    in.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+		ContentLength: int64(len(in.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+func (r *Replayer) match(got RecordedRequest) (*Interaction, error) {
+	if r.mode == MatchStrict {
+		if r.pos >= len(r.cassette.Interactions) {
+			return nil, fmt.Errorf("%w: %s %s after the last recorded call", ErrNoInteraction, got.Method, got.URL)
+		}
+
+		in := &r.cassette.Interactions[r.pos]
+		want := in.Request
+		if want.Method != got.Method || want.URL != got.URL || !sameBody(want.Body, got.Body) {
+			return nil, fmt.Errorf("%w: got %s %s, want %s %s (call %d)",
+				ErrNoInteraction, got.Method, got.URL, want.Method, want.URL, r.pos+1)
+		}
+
+		r.used[r.pos] = true
+		r.pos++
+		return in, nil
+	}
+
+	for i := range r.cassette.Interactions {
+		in := &r.cassette.Interactions[i]
+		if r.used[i] || in.Request.Method != got.Method || !sameURL(in.Request.URL, got.URL) {
+			continue
+		}
+		r.used[i] = true
+		return in, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrNoInteraction, got.Method, got.URL)
+}
+
+// Unused returns the interactions that were never replayed. A test can check
+// it is empty to make sure the code still makes every recorded call.
+func (r *Replayer) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var out []Interaction
+	for i, used := range r.used {
+		if !used {
+			out = append(out, r.cassette.Interactions[i])
+		}
+	}
+	return out
+}
+
+// sameURL compares paths exactly and query parameters in any order.
+func sameURL(a, b string) bool {
+	pathA, queryA, _ := strings.Cut(a, "?")
+	pathB, queryB, _ := strings.Cut(b, "?")
+	if pathA != pathB {
+		return false
+	}
+
+	qa, errA := url.ParseQuery(queryA)
+	qb, errB := url.ParseQuery(queryB)
+	return errA == nil && errB == nil && reflect.DeepEqual(qa, qb)
+}
+
+// sameBody compares JSON bodies by value so key order doesn't matter.
+func sameBody(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,80 @@
+package chawktest
+
+import (
+	"net/http"
+
+	"github.com/sugarvoid/chawk"
+)
+
+// The catalogs a new server starts with: Learn's built in roles.
+var (
+	defaultCourseRoles = []string{
+		chawk.RoleInstructor, chawk.RoleFacilitator, chawk.RoleTA,
+		chawk.RoleCourseBuilder, chawk.RoleGrader, chawk.RoleStudent, chawk.RoleGuest,
+	}
+	defaultInstitutionRoles = []string{"STUDENT", "FACULTY", "STAFF", "ALUMNI", "PROSPECTIVE_STUDENT", "GUEST", "OTHER", "OBSERVER"}
+	defaultSystemRoles      = []string{"SystemAdmin", "SystemSupport", "CourseCreator", "CourseSupport", "AccountAdmin", "Guest", "User", "Observer", "Integration", "Portal"}
+)
+
+func (s *Server) seedRoles() {
+	for _, id := range defaultCourseRoles {
+		s.courseRoles = append(s.courseRoles, &chawk.CourseRole{ID: s.nextID(), RoleID: id, NameForCourses: id, NameForOrganizations: id})
+	}
+	for _, id := range defaultInstitutionRoles {
+		s.institutionRoles = append(s.institutionRoles, &chawk.Role{ID: s.nextID(), RoleID: id, Name: id})
+	}
+	for _, id := range defaultSystemRoles {
+		s.systemRoles = append(s.systemRoles, &chawk.Role{ID: s.nextID(), RoleID: id, Name: id})
+	}
+}
+
+// AddCourseRole adds a custom course role, filling in the ID if unset.
+func (s *Server) AddCourseRole(r chawk.CourseRole) chawk.CourseRole {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.ID == "" {
+		r.ID = s.nextID()
+	}
+	r.Custom = true
+	s.courseRoles = append(s.courseRoles, &r)
+	return r
+}
+
+// AddInstitutionRole adds a custom institution role, filling in the ID if
+// unset.
+func (s *Server) AddInstitutionRole(r chawk.Role) chawk.Role {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.ID == "" {
+		r.ID = s.nextID()
+	}
+	r.Custom = true
+	s.institutionRoles = append(s.institutionRoles, &r)
+	return r
+}
+
+func (s *Server) routeRoles(w http.ResponseWriter, r *http.Request, kind string, segs []string) {
+	if len(segs) != 0 || r.Method != http.MethodGet {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	var items []map[string]any
+	switch kind {
+	case "courseRoles":
+		for _, role := range s.courseRoles {
+			items = append(items, toMap(*role))
+		}
+	case "institutionRoles":
+		for _, role := range s.institutionRoles {
+			items = append(items, toMap(*role))
+		}
+	case "systemRoles":
+		for _, role := range s.systemRoles {
+			items = append(items, toMap(*role))
+		}
+	}
+	s.writePage(w, r, items)
+}
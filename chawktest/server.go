@@ -0,0 +1,321 @@
+// Package chawktest has an in-memory fake of the Blackboard Learn REST API
+// for testing code built on chawk without a live Learn instance.
+//
+//	srv := chawktest.NewServer()
+//	defer srv.Close()
+//
+//	srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+//	client, _ := srv.NewClient()
+//	course, err := client.Courses.GetCourseByCourseId(ctx, "BIO-101")
+//
+// It covers the endpoints in the endpoints package: the OAuth token, users,
+// observers, uploads, courses, memberships, roles, gradebook columns,
+// announcements and discussions, with paging, fields/expand, injected faults
+// and rate limit headers.
+package chawktest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+const apiPrefix = "/learn/api/public/"
+
+// Server is a fake Learn instance. All state is kept in memory and guarded
+// by one lock, so it is safe to use from parallel tests.
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+
+	// PageSize is used when a list request doesn't ask for a limit.
+	PageSize int
+
+	// TaskPolls is how many times a course task has to be fetched before it
+	// completes. 0 completes tasks at once.
+	TaskPolls int
+
+	mu  sync.Mutex
+	seq int
+
+	users         []*chawk.User
+	courses       []*chawk.Course
+	memberships   []*Membership
+	observers     []observerLink
+	columns       map[string][]*chawk.GradebookColumn // by course primary ID
+	announcements map[string][]*chawk.Announcement
+	forums        map[string][]*forum
+	tasks         map[string]*Task
+	uploads       map[string]*Upload
+
+	courseRoles      []*chawk.CourseRole
+	institutionRoles []*chawk.Role
+	systemRoles      []*chawk.Role
+
+	tokens        map[string]tokenGrant // access token -> grant
+	refreshTokens map[string]string     // refresh token -> user ID
+	codes         map[string]string     // auth code -> user ID
+
+	faults    []*Fault
+	rateLimit *rateLimit
+	requests  []Request
+}
+
+// Membership is a course membership as the fake stores it.
+type Membership struct {
+	ID            string
+	UserID        string
+	CourseID      string // primary ID
+	ChildCourseID string
+	DataSourceID  string
+	CourseRoleID  string
+	Available     string
+	DisplayOrder  int
+	Created       time.Time
+	Modified      time.Time
+	LastAccessed  *time.Time
+}
+
+// Task is a course copy task.
+type Task struct {
+	ID              string `json:"id"`
+	Status          string `json:"status"`
+	PercentComplete int    `json:"percentComplete"`
+	Queued          string `json:"queued,omitempty"`
+	Started         string `json:"started,omitempty"`
+	Completed       string `json:"completed,omitempty"`
+
+	courseID string // primary ID of the course it's on
+	seq      int    // creation order
+	polls    int
+}
+
+// Request is one call the server received.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+}
+
+type forum struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Messages []*message `json:"-"`
+}
+
+type message struct {
+	ID     string `json:"id"`
+	UserID string `json:"userId"`
+	Body   string `json:"body"`
+}
+
+type tokenGrant struct {
+	userID string // empty for client credentials
+	expiry time.Time
+}
+
+type rateLimit struct {
+	limit     int
+	remaining int
+	reset     time.Time
+}
+
+// NewServer starts a fake Learn instance. Close it when done.
+func NewServer() *Server {
+	s := &Server{
+		ClientID:      "chawktest-key",
+		ClientSecret:  "chawktest-secret",
+		PageSize:      100,
+		columns:       map[string][]*chawk.GradebookColumn{},
+		announcements: map[string][]*chawk.Announcement{},
+		forums:        map[string][]*forum{},
+		tasks:         map[string]*Task{},
+		uploads:       map[string]*Upload{},
+		tokens:        map[string]tokenGrant{},
+		refreshTokens: map[string]string{},
+		codes:         map[string]string{},
+	}
+	s.seedRoles()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewClient returns a client for the server, with the token kept in memory.
+// opts are applied after the defaults, so they can override them.
+func (s *Server) NewClient(opts ...chawk.Option) (*chawk.BlackboardClient, error) {
+	opts = append([]chawk.Option{
+		chawk.WithTokenStore(chawk.NewMemoryTokenStore()),
+		chawk.WithHTTPClient(s.Client()),
+	}, opts...)
+	return chawk.NewClient(s.ClientID, s.ClientSecret, s.URL, opts...)
+}
+
+// Requests returns every request received so far, oldest first.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// SetRateLimit makes the server send X-Rate-Limit-* headers, counting every
+// API call against remaining. Once it hits 0 calls fail with a 429 until
+// reset has passed.
+func (s *Server) SetRateLimit(limit, remaining int, reset time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rateLimit = &rateLimit{limit: limit, remaining: remaining, reset: time.Now().Add(reset)}
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Query: r.URL.RawQuery})
+
+	if s.applyFault(w, r) {
+		return
+	}
+
+	segs, ok := splitPath(r.URL.EscapedPath())
+	if !ok || len(segs) < 2 {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	// segs[0] is the version. Every version is served the same way.
+	segs = segs[1:]
+
+	if segs[0] == "oauth2" && len(segs) == 2 && segs[1] == "token" {
+		s.handleToken(w, r)
+		return
+	}
+
+	if !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "invalid or expired token")
+		return
+	}
+
+	if !s.spendCall(w) {
+		return
+	}
+
+	switch segs[0] {
+	case "users":
+		s.routeUsers(w, r, segs[1:])
+	case "courses":
+		s.routeCourses(w, r, segs[1:])
+	case "uploads":
+		s.routeUploads(w, r, segs[1:])
+	case "courseRoles", "institutionRoles", "systemRoles":
+		s.routeRoles(w, r, segs[0], segs[1:])
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	}
+}
+
+// splitPath returns the unescaped segments after the API prefix. It works on
+// the escaped path so an escaped "/" inside an ID stays one segment.
+func splitPath(escaped string) ([]string, bool) {
+	rest, ok := strings.CutPrefix(escaped, apiPrefix)
+	if !ok {
+		return nil, false
+	}
+
+	var segs []string
+	for _, part := range strings.Split(rest, "/") {
+		if part == "" {
+			continue
+		}
+		unescaped, err := url.PathUnescape(part)
+		if err != nil {
+			return nil, false
+		}
+		segs = append(segs, unescaped)
+	}
+	return segs, true
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	grant, ok := s.tokens[token]
+	return ok && time.Now().Before(grant.expiry)
+}
+
+func (s *Server) spendCall(w http.ResponseWriter) bool {
+	rl := s.rateLimit
+	if rl == nil {
+		return true
+	}
+
+	if time.Now().After(rl.reset) {
+		rl.remaining = rl.limit
+		rl.reset = time.Now().Add(24 * time.Hour)
+	}
+
+	setRateHeaders := func() {
+		w.Header().Set("X-Rate-Limit-Limit", fmt.Sprint(rl.limit))
+		w.Header().Set("X-Rate-Limit-Remaining", fmt.Sprint(rl.remaining))
+		w.Header().Set("X-Rate-Limit-Reset", fmt.Sprint(int(time.Until(rl.reset).Seconds())))
+	}
+
+	if rl.remaining <= 0 {
+		setRateHeaders()
+		writeError(w, http.StatusTooManyRequests, "rate limit exceeded")
+		return false
+	}
+
+	rl.remaining--
+	setRateHeaders()
+	return true
+}
+
+func (s *Server) nextID() string {
+	s.seq++
+	return fmt.Sprintf("_%d_1", s.seq)
+}
+
+func newSecret() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+func now() string {
+	return time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if v != nil {
+		json.NewEncoder(w).Encode(v)
+	}
+}
+
+// writeError answers in Blackboard's error format.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("X-Blackboard-Request-Id", newSecret())
+	writeJSON(w, status, map[string]any{
+		"status":  status,
+		"message": msg,
+	})
+}
+
+func readJSON(r *http.Request, v any) error {
+	defer r.Body.Close()
+	return json.NewDecoder(r.Body).Decode(v)
+}
@@ -0,0 +1,248 @@
+package chawktest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func newTestClient(t *testing.T, s *Server, opts ...chawk.Option) *chawk.BlackboardClient {
+	t.Helper()
+	client, err := s.NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return client
+}
+
+// getJSON does a raw GET and decodes the body, so the test sees exactly
+// what the server sent.
+func getJSON(t *testing.T, client *chawk.BlackboardClient, path string) (int, map[string]any) {
+	t.Helper()
+	resp, err := client.Get(context.Background(), path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode %s: %v", path, err)
+	}
+	return resp.StatusCode, body
+}
+
+func TestServerPaging(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	s.PageSize = 2
+	client := newTestClient(t, s)
+	for _, name := range []string{"u1", "u2", "u3", "u4", "u5"} {
+		s.AddUser(chawk.User{UserName: name})
+	}
+
+	tests := []struct {
+		name     string
+		path     string
+		want     []string
+		wantNext string
+	}{
+		{
+			name:     "default page size",
+			path:     "/learn/api/public/v1/users",
+			want:     []string{"u1", "u2"},
+			wantNext: "/learn/api/public/v1/users?offset=2",
+		},
+		{
+			name:     "limit",
+			path:     "/learn/api/public/v1/users?limit=3",
+			want:     []string{"u1", "u2", "u3"},
+			wantNext: "/learn/api/public/v1/users?limit=3&offset=3",
+		},
+		{
+			name: "last page",
+			path: "/learn/api/public/v1/users?offset=4",
+			want: []string{"u5"},
+		},
+		{
+			name:     "keeps other parameters",
+			path:     "/learn/api/public/v1/users?fields=userName&limit=1&offset=1",
+			want:     []string{"u2"},
+			wantNext: "/learn/api/public/v1/users?fields=userName&limit=1&offset=2",
+		},
+		{
+			name: "past the end",
+			path: "/learn/api/public/v1/users?offset=9",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := getJSON(t, client, tt.path)
+			if status != http.StatusOK {
+				t.Fatalf("status %d", status)
+			}
+
+			var got []string
+			for _, r := range body["results"].([]any) {
+				got = append(got, r.(map[string]any)["userName"].(string))
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("results %v, want %v", got, tt.want)
+			}
+
+			next := ""
+			if paging, ok := body["paging"].(map[string]any); ok {
+				next, _ = paging["nextPage"].(string)
+			}
+			if next != tt.wantNext {
+				t.Errorf("nextPage %q, want %q", next, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestServerFieldsAndExpand(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newTestClient(t, s)
+	s.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+	s.AddUser(chawk.User{UserName: "jdoe", Name: chawk.Name{Given: "Jane", Family: "Doe"}})
+	if err := s.Enroll("BIO-101", "jdoe", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	keys := func(m map[string]any) []string {
+		var out []string
+		for k := range m {
+			out = append(out, k)
+		}
+		slices.Sort(out)
+		return out
+	}
+
+	_, body := getJSON(t, client, "/learn/api/public/v1/users/userName:jdoe?fields=userName,name.family")
+	if got := keys(body); !reflect.DeepEqual(got, []string{"name", "userName"}) {
+		t.Errorf("fields gave %v", got)
+	}
+	if name := body["name"].(map[string]any); !reflect.DeepEqual(name, map[string]any{"family": "Doe"}) {
+		t.Errorf("name = %v, want only family", name)
+	}
+
+	member := "/learn/api/public/v1/courses/courseId:BIO-101/users/userName:jdoe"
+	if _, body := getJSON(t, client, member); body["user"] != nil {
+		t.Errorf("user sent without expand: %v", body["user"])
+	}
+	_, body = getJSON(t, client, member+"?expand=user")
+	if user, _ := body["user"].(map[string]any); user["userName"] != "jdoe" {
+		t.Errorf("expand=user gave %v", body["user"])
+	}
+	_, body = getJSON(t, client, member+"?expand=user&fields=user.userName")
+	if got := keys(body); !reflect.DeepEqual(got, []string{"user"}) {
+		t.Errorf("fields with expand gave %v", got)
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newTestClient(t, s, chawk.WithRetryPolicy(chawk.RetryPolicy{}))
+	ctx := context.Background()
+	s.AddUser(chawk.User{UserName: "jdoe"})
+
+	get := func() error {
+		_, err := client.Users.Get(ctx, chawk.ByUserName("jdoe"))
+		return err
+	}
+	status := func(err error) int {
+		var apiErr *chawk.APIError
+		if errors.As(err, &apiErr) {
+			return apiErr.StatusCode
+		}
+		return 0
+	}
+
+	s.InjectFault(Fault{Method: "GET", Path: "/users/", Status: 502, Times: 2})
+	s.InjectFault(Fault{Method: "DELETE", Status: 403})
+	for i := range 2 {
+		if err := get(); status(err) != 502 {
+			t.Errorf("call %d: err = %v, want 502", i, err)
+		}
+	}
+	if err := get(); err != nil {
+		t.Errorf("fault outlived Times: %v", err)
+	}
+
+	// The DELETE fault never matched a GET above, and lasts until cleared.
+	if err := client.Users.Delete(ctx, chawk.ByUserName("jdoe")); status(err) != 403 {
+		t.Errorf("delete err = %v, want 403", err)
+	}
+	s.ClearFaults()
+	if err := client.Users.Delete(ctx, chawk.ByUserName("jdoe")); err != nil {
+		t.Errorf("delete after ClearFaults: %v", err)
+	}
+
+	s.InjectFault(Fault{Status: 418, Body: `{"status":418,"message":"teapot"}`, Header: http.Header{"X-Test": {"yes"}}})
+	resp, err := client.Get(ctx, "/learn/api/public/v1/users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != 418 || resp.Header.Get("X-Test") != "yes" {
+		t.Errorf("got %d with X-Test %q", resp.StatusCode, resp.Header.Get("X-Test"))
+	}
+}
+
+func TestServerRateLimit(t *testing.T) {
+	s := NewServer()
+	defer s.Close()
+	client := newTestClient(t, s)
+	ctx := context.Background()
+	s.AddUser(chawk.User{UserName: "jdoe"})
+	s.SetRateLimit(100, 2, time.Hour)
+
+	if client.RateLimit().Known() {
+		t.Fatal("rate limit known before any call")
+	}
+
+	for want := 1; want >= 0; want-- {
+		if _, err := client.Users.Get(ctx, chawk.ByUserName("jdoe")); err != nil {
+			t.Fatal(err)
+		}
+		rl := client.RateLimit()
+		if rl.Limit != 100 || rl.Remaining != want {
+			t.Errorf("RateLimit = %d/%d, want %d/100", rl.Remaining, rl.Limit, want)
+		}
+		if until := time.Until(rl.Reset); until < 59*time.Minute || until > time.Hour {
+			t.Errorf("Reset in %v, want about an hour", until)
+		}
+	}
+
+	// Out of calls: the reset is too far away for the default retry policy
+	// to wait for, so the 429 comes straight back.
+	before := len(s.Requests())
+	_, err := client.Users.Get(ctx, chawk.ByUserName("jdoe"))
+	var apiErr *chawk.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("err = %v, want a 429", err)
+	}
+	if n := len(s.Requests()) - before; n != 1 {
+		t.Errorf("sent %d requests, want 1", n)
+	}
+
+	// Once the reset passes the quota refills.
+	s.SetRateLimit(100, 0, -time.Second)
+	if _, err := client.Users.Get(ctx, chawk.ByUserName("jdoe")); err != nil {
+		t.Fatalf("after reset: %v", err)
+	}
+	if rl := client.RateLimit(); rl.Remaining != 99 {
+		t.Errorf("Remaining = %d after reset, want 99", rl.Remaining)
+	}
+}
@@ -0,0 +1,93 @@
+package chawktest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const tokenLifetime = time.Hour
+
+// IssueCode returns an authorization code for userName, as if the user had
+// logged in and approved the app. Exchange it with client.ExchangeCode.
+func (s *Server) IssueCode(userName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u := s.findUser("userName:" + userName)
+	if u == nil {
+		return "", fmt.Errorf("no user %q", userName)
+	}
+
+	code := newSecret()
+	s.codes[code] = u.ID
+	return code, nil
+}
+
+// ExpireTokens makes every access token handed out so far invalid.
+func (s *Server) ExpireTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.tokens)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "token endpoint only takes POST")
+		return
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if !ok || id != s.ClientID || secret != s.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid client credentials")
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var userID string
+
+	switch r.Form.Get("grant_type") {
+	case "client_credentials":
+	case "authorization_code":
+		code := r.Form.Get("code")
+		userID, ok = s.codes[code]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid authorization code")
+			return
+		}
+		delete(s.codes, code)
+	case "refresh_token":
+		refresh := r.Form.Get("refresh_token")
+		userID, ok = s.refreshTokens[refresh]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalid refresh token")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported grant_type")
+		return
+	}
+
+	access := newSecret()
+	s.tokens[access] = tokenGrant{userID: userID, expiry: time.Now().Add(tokenLifetime)}
+
+	resp := map[string]any{
+		"access_token": access,
+		"token_type":   "bearer",
+		"expires_in":   int(tokenLifetime.Seconds()),
+	}
+
+	if userID != "" {
+		refresh := newSecret()
+		s.refreshTokens[refresh] = userID
+		resp["refresh_token"] = refresh
+		resp["user_id"] = userID
+		resp["scope"] = "read write offline"
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
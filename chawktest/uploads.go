@@ -0,0 +1,63 @@
+package chawktest
+
+import (
+	"io"
+	"net/http"
+)
+
+// Upload is a file posted to /uploads.
+type Upload struct {
+	ID          string
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// maxUpload is the largest file the fake takes.
+const maxUpload = 32 << 20
+
+// Upload returns the upload with id.
+func (s *Server) Upload(id string) (Upload, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.uploads[id]
+	if !ok {
+		return Upload{}, false
+	}
+	return *u, true
+}
+
+func (s *Server) routeUploads(w http.ResponseWriter, r *http.Request, segs []string) {
+	if len(segs) != 0 || r.Method != http.MethodPost {
+		writeError(w, http.StatusNotFound, "no such endpoint")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "no file in request: "+err.Error())
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxUpload+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if len(data) > maxUpload {
+		writeError(w, http.StatusRequestEntityTooLarge, "file is too large")
+		return
+	}
+
+	u := &Upload{
+		ID:          s.nextID(),
+		FileName:    header.Filename,
+		ContentType: header.Header.Get("Content-Type"),
+		Data:        data,
+	}
+	s.uploads[u.ID] = u
+
+	writeJSON(w, http.StatusCreated, map[string]any{"id": u.ID})
+}
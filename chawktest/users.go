@@ -0,0 +1,323 @@
+package chawktest
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func (s *Server) routeUsers(w http.ResponseWriter, r *http.Request, segs []string) {
+	switch {
+	case len(segs) == 0 && r.Method == http.MethodGet:
+		s.listUsers(w, r)
+	case len(segs) == 0 && r.Method == http.MethodPost:
+		s.createUser(w, r)
+	case len(segs) == 1:
+		u := s.findUser(segs[0])
+		if u == nil {
+			writeError(w, http.StatusNotFound, "user not found")
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeObject(w, r, http.StatusOK, userMap(u))
+		case http.MethodPatch:
+			s.patchUser(w, r, u)
+		case http.MethodDelete:
+			s.deleteUser(w, u)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		}
+	case len(segs) == 2 && segs[1] == "courses" && r.Method == http.MethodGet:
+		u := s.findUser(segs[0])
+		if u == nil {
+			writeError(w, http.StatusNotFound, "user not found")
+			return
+		}
+		s.listUserCourses(w, r, u)
+	case len(segs) >= 2 && (segs[1] == "observers" || segs[1] == "observees"):
+		s.routeObservers(w, r, segs)
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint")
+	}
+}
+
+// userMap is the JSON form of a user. Passwords are never sent back.
+func userMap(u *chawk.User) map[string]any {
+	cp := *u
+	cp.Password = ""
+	return toMap(cp)
+}
+
+func (s *Server) listUsers(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if err := checkDates(q, "created", "modified"); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var users []*chawk.User
+	for _, u := range s.users {
+		ok, err := matchUser(u, q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if ok {
+			users = append(users, u)
+		}
+	}
+
+	if sortBy := q.Get("sort"); sortBy != "" {
+		field, desc := strings.CutSuffix(sortBy, "(desc)")
+		slices.SortStableFunc(users, func(a, b *chawk.User) int {
+			c := cmp.Compare(sortKey(a, field), sortKey(b, field))
+			if desc {
+				return -c
+			}
+			return c
+		})
+	}
+
+	var items []map[string]any
+	for _, u := range users {
+		items = append(items, userMap(u))
+	}
+	s.writePage(w, r, items)
+}
+
+// matchUser applies the filters Learn supports on GET /users.
+func matchUser(u *chawk.User, q url.Values) (bool, error) {
+	contains := map[string]string{
+		"userName":      u.UserName,
+		"externalId":    u.ExternalID,
+		"studentId":     u.StudentID,
+		"name.given":    u.Name.Given,
+		"name.family":   u.Name.Family,
+		"contact.email": u.Contact.Email,
+	}
+	for param, value := range contains {
+		if want := q.Get(param); want != "" && !strings.Contains(strings.ToLower(value), strings.ToLower(want)) {
+			return false, nil
+		}
+	}
+
+	if want := q.Get("dataSourceId"); want != "" && u.DataSourceID != want {
+		return false, nil
+	}
+	if want := q.Get("availability.available"); want != "" && u.Availability.Available != want {
+		return false, nil
+	}
+	if want := q.Get("institutionRoleIds"); want != "" {
+		if !slices.ContainsFunc(strings.Split(want, ","), func(role string) bool {
+			return slices.Contains(u.InstitutionRoleIDs, role)
+		}) {
+			return false, nil
+		}
+	}
+
+	for param, got := range map[string]*time.Time{"created": u.Created, "modified": u.Modified} {
+		if ok, err := matchDate(q, param, got); !ok || err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchDate applies a created/modified filter with its Compare parameter.
+func matchDate(q url.Values, param string, got *time.Time) (bool, error) {
+	want := q.Get(param)
+	if want == "" {
+		return true, nil
+	}
+
+	bound, err := time.Parse(time.RFC3339, want)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s: %w", param, err)
+	}
+	if got == nil {
+		return false, nil
+	}
+
+	if q.Get(param+"Compare") == "lessThan" {
+		return got.Before(bound), nil
+	}
+	return !got.Before(bound), nil
+}
+
+// checkDates rejects the Compare values Learn rejects, even when there is
+// nothing to filter. Learn only takes lessThan and greaterOrEqual.
+func checkDates(q url.Values, params ...string) error {
+	for _, param := range params {
+		switch q.Get(param + "Compare") {
+		case "lessThan", "greaterOrEqual", "":
+		default:
+			return fmt.Errorf("invalid %sCompare %q", param, q.Get(param+"Compare"))
+		}
+	}
+	return nil
+}
+
+// sortableTime keeps every digit so timestamps sort as strings.
+const sortableTime = "2006-01-02T15:04:05.000000000Z"
+
+func sortKey(u *chawk.User, field string) string {
+	switch field {
+	case "userName":
+		return u.UserName
+	case "externalId":
+		return u.ExternalID
+	case "studentId":
+		return u.StudentID
+	case "name.given":
+		return u.Name.Given
+	case "name.family":
+		return u.Name.Family
+	case "created":
+		if u.Created != nil {
+			return u.Created.UTC().Format(sortableTime)
+		}
+	case "modified":
+		if u.Modified != nil {
+			return u.Modified.UTC().Format(sortableTime)
+		}
+	}
+	return ""
+}
+
+func (s *Server) createUser(w http.ResponseWriter, r *http.Request) {
+	var u chawk.User
+	if err := readJSON(r, &u); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if u.UserName == "" {
+		writeError(w, http.StatusBadRequest, "userName is required")
+		return
+	}
+	if s.findUser("userName:"+u.UserName) != nil {
+		writeError(w, http.StatusConflict, "a user with that userName already exists")
+		return
+	}
+
+	// Server managed fields can't be set by the caller.
+	u.ID, u.UUID, u.Created, u.Modified, u.LastLogin = "", "", nil, nil, nil
+
+	writeObject(w, r, http.StatusCreated, userMap(s.insertUser(u)))
+}
+
+func (s *Server) patchUser(w http.ResponseWriter, r *http.Request, u *chawk.User) {
+	var patch map[string]any
+	if err := readJSON(r, &patch); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	merged := toMap(*u)
+	mergePatch(merged, patch)
+
+	var updated chawk.User
+	if err := fromMap(merged, &updated); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if updated.UserName == "" {
+		writeError(w, http.StatusBadRequest, "userName can't be removed")
+		return
+	}
+	if other := s.findUser("userName:" + updated.UserName); other != nil && other != u {
+		writeError(w, http.StatusConflict, "a user with that userName already exists")
+		return
+	}
+
+	updated.ID, updated.UUID, updated.Created = u.ID, u.UUID, u.Created
+	updated.Modified = chawk.ToPtr(time.Now().UTC())
+	*u = updated
+
+	writeObject(w, r, http.StatusOK, userMap(u))
+}
+
+func (s *Server) deleteUser(w http.ResponseWriter, u *chawk.User) {
+	for i, existing := range s.users {
+		if existing == u {
+			s.users = append(s.users[:i], s.users[i+1:]...)
+			break
+		}
+	}
+
+	kept := s.memberships[:0]
+	for _, m := range s.memberships {
+		if m.UserID != u.ID {
+			kept = append(kept, m)
+		}
+	}
+	s.memberships = kept
+
+	links := s.observers[:0]
+	for _, l := range s.observers {
+		if l.observerID != u.ID && l.observeeID != u.ID {
+			links = append(links, l)
+		}
+	}
+	s.observers = links
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listUserCourses(w http.ResponseWriter, r *http.Request, u *chawk.User) {
+	var items []map[string]any
+	for _, m := range s.memberships {
+		if m.UserID != u.ID {
+			continue
+		}
+		items = append(items, s.membershipMap(r, m))
+	}
+	s.writePage(w, r, items)
+}
+
+// membershipMap is the JSON form of a membership, with the user and course
+// objects added when the request expands them.
+func (s *Server) membershipMap(r *http.Request, m *Membership) map[string]any {
+	out := map[string]any{
+		"id":           m.ID,
+		"userId":       m.UserID,
+		"courseId":     m.CourseID,
+		"courseRoleId": m.CourseRoleID,
+		"availability": map[string]any{"available": m.Available},
+		"created":      m.Created.UTC().Format(time.RFC3339Nano),
+		"modified":     m.Modified.UTC().Format(time.RFC3339Nano),
+		"displayOrder": m.DisplayOrder,
+	}
+	if m.ChildCourseID != "" {
+		out["childCourseId"] = m.ChildCourseID
+	}
+	if m.DataSourceID != "" {
+		out["dataSourceId"] = m.DataSourceID
+	}
+	if m.LastAccessed != nil {
+		out["lastAccessed"] = m.LastAccessed.UTC().Format(time.RFC3339Nano)
+	}
+
+	if expands(r, "user") {
+		if u := s.findUser(m.UserID); u != nil {
+			out["user"] = userMap(u)
+		}
+	}
+	if expands(r, "course") {
+		if c := s.findCourse(m.CourseID); c != nil {
+			out["course"] = toMap(*c)
+		}
+	}
+
+	return out
+}
@@ -7,9 +7,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
-	"os"
-	"path/filepath"
+	"net/url"
 	"sync"
 	"time"
 
@@ -20,7 +20,8 @@ var (
 	ErrInsufficientPrivileges = errors.New("insufficient privileges")
 	ErrClientInitError        = errors.New("clientID, clientSecret, and baseURL are required")
 	ErrTokenExpired           = errors.New("token expired")
-	ErrTokenMissing           = errors.New("token file missing")
+	ErrTokenMissing           = errors.New("cached token missing")
+	ErrRefreshTokenMissing    = errors.New("user token expired and has no refresh token")
 )
 
 const (
@@ -33,65 +34,105 @@ type BlackboardClient struct {
 	clientSecret string
 	BaseURL      string
 	token        *Token
-	tokenFile    string
+	tokenStore   TokenStore
 	httpClient   *http.Client
+	logger       *slog.Logger
+	delegated    bool // acts as a user, see ForUser
+	rateLimit    *rateLimitTracker
+	middleware   []Middleware
 
 	UserAgent string
 
+	// Retry is used for every call made through sendRequest.
+	// Defaults to DefaultRetryPolicy().
+	Retry RetryPolicy
+
 	// Sub Services
 	Users        *UserService
 	Courses      *CourseService
 	Announcement *AnnouncementService
 	Gradebook    *GradebookService
+	Roles        *RoleService
+	Uploads      *UploadService
 }
 
 // NewClient initializes and returns a new Blackboard API Client.
 // It requires a Client ID and Client Secret obtained from the
-// Blackboard Developer Portal.
-func NewClient(clientID, clientSecret, baseURL, tokenPath string) (*BlackboardClient, error) {
+// Blackboard Developer Portal. Everything else can be changed with options,
+// by default the token is cached in data/.token.json.
+func NewClient(clientID, clientSecret, baseURL string, opts ...Option) (*BlackboardClient, error) {
 	if clientID == "" || clientSecret == "" || baseURL == "" {
 		return nil, ErrClientInitError
 	}
 
-	if tokenPath == "" {
-		tokenPath = "data/.token.json"
+	baseURL, err := normalizeBaseURL(baseURL)
+	if err != nil {
+		return nil, err
 	}
 
 	client := &BlackboardClient{
 		clientID:     clientID,
 		clientSecret: clientSecret,
 		BaseURL:      baseURL,
-		tokenFile:    tokenPath,
 		httpClient:   &http.Client{Timeout: HTTP_TIMEOUT_SECS * time.Second},
+		logger:       slog.New(slog.DiscardHandler),
+		rateLimit:    newRateLimitTracker(),
+		Retry:        DefaultRetryPolicy(),
+	}
+
+	for _, opt := range opts {
+		if err := opt(client); err != nil {
+			return nil, err
+		}
 	}
 
-	client.Users = &UserService{client: client}
-	client.Courses = &CourseService{client: client}
-	client.Announcement = &AnnouncementService{client: client}
-	client.Gradebook = &GradebookService{client: client}
+	if client.tokenStore == nil {
+		client.tokenStore = NewFileTokenStore("data/.token.json")
+	}
 
-	// Attempt to load token from file, ignore error if file missing or expired
+	client.initServices()
+
+	// Attempt to load token from the store, ignore error if missing or expired
 	// We will make a new one later
 	client.loadToken()
 
 	return client, nil
 }
 
-// loadToken loads token data from a JSON file
-func (c *BlackboardClient) loadToken() error {
-	file, err := os.Open(c.tokenFile)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return ErrTokenMissing
-		}
-		return fmt.Errorf("failed to open token file: %w", err)
+func (c *BlackboardClient) initServices() {
+	c.Users = &UserService{client: c}
+	c.Courses = &CourseService{client: c}
+	c.Announcement = &AnnouncementService{client: c}
+	c.Gradebook = &GradebookService{client: c}
+	c.Roles = &RoleService{client: c}
+	c.Uploads = &UploadService{client: c}
+}
+
+// NewClientWithTokenPath is the old NewClient signature, kept so existing
+// callers only need a rename. An empty tokenPath means data/.token.json.
+func NewClientWithTokenPath(clientID, clientSecret, baseURL, tokenPath string) (*BlackboardClient, error) {
+	if tokenPath == "" {
+		return NewClient(clientID, clientSecret, baseURL)
 	}
-	defer file.Close()
+	return NewClient(clientID, clientSecret, baseURL, WithTokenPath(tokenPath))
+}
+
+// SetTokenStore swaps where the client caches its token, and picks up
+// the token already in the new store if it is still good.
+func (c *BlackboardClient) SetTokenStore(store TokenStore) {
+	c.mu.Lock()
+	c.tokenStore = store
+	c.token = nil
+	c.mu.Unlock()
 
-	t := &Token{}
+	c.loadToken()
+}
 
-	if err := json.NewDecoder(file).Decode(t); err != nil {
-		return fmt.Errorf("failed to decode token file: %w", err)
+// loadToken loads the cached token from the token store
+func (c *BlackboardClient) loadToken() error {
+	t, err := c.tokenStore.Load(context.Background())
+	if err != nil {
+		return err
 	}
 
 	if t.IsExpired() {
@@ -105,101 +146,144 @@ func (c *BlackboardClient) loadToken() error {
 	return nil
 }
 
-// saveToken saves token data to a JSON file
-func (c *BlackboardClient) saveToken(t *Token) error {
-	// Ensure directory exists
-	dir := filepath.Dir(c.tokenFile)
-	if err := os.MkdirAll(dir, 0700); err != nil {
-		return fmt.Errorf("failed to create token directory: %w", err)
+// requestNewToken gets a new token, with the client credentials flow for
+// app clients and the refresh token for clients made with ForUser.
+func (c *BlackboardClient) requestNewToken(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Do we evern need a new token?
+	if c.token != nil && !c.token.IsExpired() {
+		return nil
 	}
 
-	// Marshal and write to file
-	data, err := json.Marshal(t)
+	current := c.token
+
+	// Shared stores get locked, then re-checked, since another process
+	// may have refreshed the token while we were waiting.
+	if locker, ok := c.tokenStore.(TokenLocker); ok {
+		unlock, err := locker.Lock(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to lock token store: %w", err)
+		}
+		defer unlock()
+
+		if t, err := c.tokenStore.Load(ctx); err == nil {
+			if !t.IsExpired() {
+				c.logger.DebugContext(ctx, "using token refreshed by another process")
+				c.token = t
+				return nil
+			}
+			current = t
+		}
+	}
+
+	var newToken *Token
+	var err error
+
+	c.logger.InfoContext(ctx, "requesting new token", "delegated", c.delegated)
+
+	if c.delegated {
+		// A user's token can only be refreshed, the app secret alone can't get it back.
+		if current == nil || current.RefreshToken == "" {
+			return ErrRefreshTokenMissing
+		}
+		newToken, err = c.fetchToken(ctx, "refresh_token", url.Values{"refresh_token": {current.RefreshToken}})
+		if err == nil && newToken.RefreshToken == "" {
+			newToken.RefreshToken = current.RefreshToken
+		}
+	} else {
+		newToken, err = c.fetchToken(ctx, "client_credentials", nil)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to marshal token: %w", err)
+		c.logger.ErrorContext(ctx, "token request failed", "error", err)
+		return err
 	}
 
-	if err := os.WriteFile(c.tokenFile, data, 0600); err != nil {
-		return fmt.Errorf("failed to write token file: %w", err)
+	c.logger.DebugContext(ctx, "got new token", "expires", newToken.Expiry)
+	c.token = newToken
+
+	// save toke to cache file
+	// Failing should be okay. Check this later
+	err = c.tokenStore.Save(ctx, newToken)
+	if err != nil {
+		c.logger.WarnContext(ctx, "failed to save token", "error", err)
 	}
 
-	c.token = t
 	return nil
 }
 
-// requestNewToken performs OAuth2 client credentials flow to get a new token
-func (c *BlackboardClient) requestNewToken(ctx context.Context) error {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	// Do we evern need a new token?
-	if c.token != nil && !c.token.IsExpired() {
-		return nil
+// fetchToken posts to the token endpoint with the app's credentials.
+// Blackboard wants everything but the grant type in the query string.
+func (c *BlackboardClient) fetchToken(ctx context.Context, grantType string, params url.Values) (*Token, error) {
+	tokenURL := c.BaseURL + endpoints.GetToken()
+	if len(params) > 0 {
+		tokenURL += "?" + params.Encode()
 	}
+	form := url.Values{"grant_type": {grantType}}.Encode()
 
-	url := c.BaseURL + endpoints.GetToken()
-	form := "grant_type=client_credentials"
-
-	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBufferString(form))
+	req, err := http.NewRequestWithContext(ctx, "POST", tokenURL, bytes.NewBufferString(form))
 	if err != nil {
-		return fmt.Errorf("failed to create token request: %w", err)
+		return nil, fmt.Errorf("failed to create token request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 	req.SetBasicAuth(c.clientID, c.clientSecret)
 
-	resp, err := c.httpClient.Do(req)
+	// The token request is a POST, but asking for a token twice is harmless.
+	// Auth codes are single use, so those are only sent once. It isn't
+	// throttled: token calls don't spend the quota, and a client held at
+	// the reserve still has to be able to log in.
+	resp, err := c.doWithRetry(req, grantType != "authorization_code", false)
 	if err != nil {
-		return fmt.Errorf("failed to execute token request: %w", err)
+		return nil, fmt.Errorf("failed to execute token request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf("failed to get token: status %d, body: %s", resp.StatusCode, string(bodyBytes))
+		return nil, fmt.Errorf("failed to get token: %w", newAPIError(resp, bodyBytes, nil))
 	}
 
 	// make token
 	newToken := &Token{}
-	if err := json.NewDecoder(resp.Body).Decode(newToken); err != nil {
-		return fmt.Errorf("failed to decode token response: %w", err)
+	if err := json.NewDecoder(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE)).Decode(newToken); err != nil {
+		return nil, fmt.Errorf("failed to decode token response: %w", err)
 	}
 
 	newToken.Expiry = time.Now().Add(time.Duration(newToken.ExpiresIn) * time.Second)
 
 	if newToken.AccessToken == "" {
-		return errors.New("received empty access token from blackboard")
-	}
-
-	//c.token = newToken
-
-	// save toke to cache file
-	// Failing should be okay. Check this later
-	err = c.saveToken(newToken)
-	if err != nil {
-		fmt.Printf("Warning: failed to save token to file: %v\n", err)
+		return nil, errors.New("received empty access token from blackboard")
 	}
 
-	return nil
+	return newToken, nil
 }
 
 func (c *BlackboardClient) sendRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Response, error) {
-	// If the token is nil OR expired, try to get a new one.
-	// requestNewToken handles its own locking, so no need to call it before this.
-	if c.token == nil || c.token.IsExpired() {
-		if err := c.requestNewToken(ctx); err != nil {
-			return nil, fmt.Errorf("auth failure: %w", err)
-		}
-	}
+	return c.sendWithType(ctx, method, path, "application/json", body)
+}
 
-	// Store token in memeory.
+// sendWithType is sendRequest for bodies that aren't JSON, like uploads.
+func (c *BlackboardClient) sendWithType(ctx context.Context, method, path, contentType string, body io.Reader) (*http.Response, error) {
+	// If the token is nil OR expired, try to get a new one.
+	// requestNewToken checks again under the write lock, so only one of
+	// several concurrent callers actually fetches it.
 	c.mu.RLock()
 	token := c.token
 	c.mu.RUnlock()
 
-	url := c.BaseURL + path
+	if token == nil || token.IsExpired() {
+		if err := c.requestNewToken(ctx); err != nil {
+			return nil, fmt.Errorf("auth failure: %w", err)
+		}
 
-	req, err := http.NewRequestWithContext(ctx, method, url, body)
+		c.mu.RLock()
+		token = c.token
+		c.mu.RUnlock()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
@@ -213,10 +297,10 @@ func (c *BlackboardClient) sendRequest(ctx context.Context, method string, path
 	}
 
 	if body != nil && (method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch) {
-		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Type", contentType)
 	}
 
-	return c.httpClient.Do(req)
+	return c.doWithRetry(req, c.Retry.allowsMethod(method), true)
 }
 
 func (c *BlackboardClient) Get(ctx context.Context, path string) (*http.Response, error) {
@@ -251,8 +335,14 @@ func (c *BlackboardClient) Delete(ctx context.Context, path string) (*http.Respo
 	return c.sendRequest(ctx, http.MethodDelete, path, nil)
 }
 
-// GetRemainingCalls returns the number of apis calls left of the key. This does consume a call.
+// GetRemainingCalls returns the number of apis calls left of the key.
+// It only consumes a call when no response has reported the quota yet,
+// or the quota has reset since; use RateLimit to never make one.
 func (c *BlackboardClient) GetRemainingCalls(ctx context.Context) (int, error) {
+	if s := c.RateLimit(); s.Known() && (s.Reset.IsZero() || time.Now().Before(s.Reset)) {
+		return s.Remaining, nil
+	}
+
 	// Hit a lightweight endpoint to get headers
 	resp, err := c.Get(ctx, "/learn/api/public/v1/users?limit=1")
 	if err != nil {
@@ -260,6 +350,11 @@ func (c *BlackboardClient) GetRemainingCalls(ctx context.Context) (int, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return 0, newAPIError(resp, body, nil)
+	}
+
 	remaining := resp.Header.Get("X-Rate-Limit-Remaining")
 	if remaining == "" {
 		return 0, errors.New("X-Rate-Limit-Remaining header not found")
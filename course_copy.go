@@ -0,0 +1,331 @@
+package chawk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+var ErrTaskFailed = errors.New("course task failed")
+
+// What a copy does with discussions.
+const (
+	DiscussionsNone        = "None"
+	DiscussionsForumsOnly  = "ForumsOnly"
+	DiscussionsWithStarter = "ForumsAndStarterPosts"
+)
+
+// Course task statuses. Anything else means the task failed.
+const (
+	TaskQueued   = "Queued"
+	TaskRunning  = "Running"
+	TaskComplete = "Complete"
+)
+
+// DefaultPollInterval is how often WaitForTask checks a task when it isn't
+// told otherwise.
+const DefaultPollInterval = 5 * time.Second
+
+// CopyOptions says what a course copy takes along. Start from one of the
+// presets (FullCopy, ContentOnlyCopy, ExistingCourseCopy) and adjust.
+type CopyOptions struct {
+	AdaptiveReleaseRules bool         `json:"adaptiveReleaseRules"`
+	Announcements        bool         `json:"announcements"`
+	Assessments          bool         `json:"assessments"`
+	Blogs                bool         `json:"blogs"`
+	Calendar             bool         `json:"calendar"`
+	Contacts             bool         `json:"contacts"`
+	ContentAlignments    bool         `json:"contentAlignments"`
+	ContentAreas         bool         `json:"contentAreas"`
+	Discussions          string       `json:"discussions"` // one of the Discussions constants, "" means DiscussionsNone
+	Glossary             bool         `json:"glossary"`
+	Gradebook            bool         `json:"gradebook"`
+	GroupSettings        bool         `json:"groupSettings"`
+	Journals             bool         `json:"journals"`
+	RetentionRules       bool         `json:"retentionRules"`
+	Rubrics              bool         `json:"rubrics"`
+	Settings             CopySettings `json:"settings"`
+	Tasks                bool         `json:"tasks"`
+	Wikis                bool         `json:"wikis"`
+}
+
+// CopySettings are the course settings a copy takes along.
+type CopySettings struct {
+	Availability       bool `json:"availability"`
+	BannerImage        bool `json:"bannerImage"`
+	Duration           bool `json:"duration"`
+	EnrollmentOptions  bool `json:"enrollmentOptions"`
+	GuestAccess        bool `json:"guestAccess"`
+	LanguagePack       bool `json:"languagePack"`
+	NavigationSettings bool `json:"navigationSettings"`
+	ObserverAccess     bool `json:"observerAccess"`
+}
+
+// FullCopy copies everything but the course's availability, so a new copy
+// starts out however the server defaults new courses.
+func FullCopy() CopyOptions {
+	return CopyOptions{
+		AdaptiveReleaseRules: true,
+		Announcements:        true,
+		Assessments:          true,
+		Blogs:                true,
+		Calendar:             true,
+		Contacts:             true,
+		ContentAlignments:    true,
+		ContentAreas:         true,
+		Discussions:          DiscussionsWithStarter,
+		Glossary:             true,
+		Gradebook:            true,
+		GroupSettings:        true,
+		Journals:             true,
+		RetentionRules:       true,
+		Rubrics:              true,
+		Settings: CopySettings{
+			BannerImage:        true,
+			Duration:           true,
+			EnrollmentOptions:  true,
+			GuestAccess:        true,
+			LanguagePack:       true,
+			NavigationSettings: true,
+			ObserverAccess:     true,
+		},
+		Tasks: true,
+		Wikis: true,
+	}
+}
+
+// ContentOnlyCopy copies the teaching material (content, tests, rubrics,
+// the gradebook and empty forums) and leaves out dated and social things
+// like announcements, the calendar and settings.
+func ContentOnlyCopy() CopyOptions {
+	return CopyOptions{
+		AdaptiveReleaseRules: true,
+		Assessments:          true,
+		ContentAlignments:    true,
+		ContentAreas:         true,
+		Discussions:          DiscussionsForumsOnly,
+		Glossary:             true,
+		Gradebook:            true,
+		Rubrics:              true,
+	}
+}
+
+// ExistingCourseCopy is FullCopy without the settings, for copying into a
+// course that already has its own.
+func ExistingCourseCopy() CopyOptions {
+	opts := FullCopy()
+	opts.Settings = CopySettings{}
+	return opts
+}
+
+type copyRequest struct {
+	TargetCourse struct {
+		ID       string `json:"id,omitempty"`
+		CourseID string `json:"courseId,omitempty"`
+	} `json:"targetCourse"`
+	Copy CopyOptions `json:"copy"`
+}
+
+// CopyCourse copies source into a new course called newCourseID. The copy
+// runs in the background on Learn; the returned task URI can be passed to
+// WaitForTask.
+func (cs *CourseService) CopyCourse(ctx context.Context, source CourseRef, newCourseID string, opts CopyOptions) (string, error) {
+	newCourseID = strings.TrimSpace(newCourseID)
+	if err := ValidateCourseID(newCourseID); err != nil {
+		return "", err
+	}
+
+	var req copyRequest
+	req.TargetCourse.CourseID = newCourseID
+	req.Copy = opts
+	return cs.copy(ctx, source, req)
+}
+
+// CopyIntoCourse copies source into the existing course target. Like
+// CopyCourse it returns a task URI for WaitForTask.
+func (cs *CourseService) CopyIntoCourse(ctx context.Context, source, target CourseRef, opts CopyOptions) (string, error) {
+	// Learn wants the target's primary ID.
+	t, err := cs.Get(ctx, target)
+	if err != nil {
+		return "", fmt.Errorf("failed to get target course %s: %w", target, err)
+	}
+
+	var req copyRequest
+	req.TargetCourse.ID = t.ID
+	req.Copy = opts
+	return cs.copy(ctx, source, req)
+}
+
+func (cs *CourseService) copy(ctx context.Context, source CourseRef, req copyRequest) (string, error) {
+	path, err := coursePath(source)
+	if err != nil {
+		return "", err
+	}
+	if req.Copy.Discussions == "" {
+		req.Copy.Discussions = DiscussionsNone
+	}
+
+	resp, err := cs.client.Post(ctx, endpoints.Courses.Copy(path), req)
+	if err != nil {
+		return "", fmt.Errorf("failed to copy course %s: %w", source, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusAccepted:
+		taskURI := resp.Header.Get("Location")
+		if taskURI == "" {
+			return "", fmt.Errorf("202 Accepted received but Location header was missing")
+		}
+		cs.client.logger.InfoContext(ctx, "course copy started", "source", source, "task", taskURI)
+		return taskURI, nil
+	case http.StatusNotFound:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return "", newAPIError(resp, body, ErrCourseNotFound)
+	case http.StatusConflict:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return "", newAPIError(resp, body, ErrCourseExist)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return "", newAPIError(resp, body, nil)
+	}
+}
+
+// CourseTask is a background job on a course, such as a copy.
+type CourseTask struct {
+	ID              string    `json:"id"`
+	Status          string    `json:"status"` // TaskQueued, TaskRunning, TaskComplete or a failure
+	PercentComplete int       `json:"percentComplete"`
+	Queued          time.Time `json:"queued,omitzero"`
+	Started         time.Time `json:"started,omitzero"`
+	Completed       time.Time `json:"completed,omitzero"`
+}
+
+// Done reports whether the task has stopped, one way or the other.
+func (t CourseTask) Done() bool {
+	return t.Status != TaskQueued && t.Status != TaskRunning
+}
+
+// GetTask fetches a task of course.
+func (cs *CourseService) GetTask(ctx context.Context, course CourseRef, taskID string) (*CourseTask, error) {
+	path, err := coursePath(course)
+	if err != nil {
+		return nil, err
+	}
+	taskID = strings.TrimSpace(taskID)
+	if taskID == "" {
+		return nil, fmt.Errorf("task ID is required: %w", ErrEmptyStringParameter)
+	}
+
+	resp, err := cs.client.Get(ctx, endpoints.Courses.GetTask(path, url.PathEscape(taskID)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get task %s: %w", taskID, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var task CourseTask
+		if err := json.NewDecoder(resp.Body).Decode(&task); err != nil {
+			return nil, fmt.Errorf("failed to decode task: %w", err)
+		}
+		return &task, nil
+	case http.StatusNotFound:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return nil, newAPIError(resp, body, ErrCourseNotFound)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return nil, newAPIError(resp, body, nil)
+	}
+}
+
+// ListTasks returns the tasks of course.
+func (cs *CourseService) ListTasks(ctx context.Context, course CourseRef) ([]CourseTask, error) {
+	path, err := coursePath(course)
+	if err != nil {
+		return nil, err
+	}
+
+	pager := NewPager[CourseTask](cs.client, endpoints.Courses.ListTasks(path))
+	pager.NotFound = ErrCourseNotFound
+
+	tasks, err := collect(pager.All(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tasks of %s: %w", course, err)
+	}
+	return tasks, nil
+}
+
+// WaitForTask polls the task at taskURI (as returned by CopyCourse) every
+// pollInterval until it completes. progress, if given, is called with every
+// poll. It returns the last state of the task, and an error wrapping
+// ErrTaskFailed if the task didn't complete.
+//
+//	task, err := client.Courses.WaitForTask(ctx, uri, 10*time.Second, func(t chawk.CourseTask) {
+//		fmt.Printf("%s %d%%\n", t.Status, t.PercentComplete)
+//	})
+func (cs *CourseService) WaitForTask(ctx context.Context, taskURI string, pollInterval time.Duration, progress ...func(CourseTask)) (*CourseTask, error) {
+	course, taskID, err := parseTaskURI(taskURI)
+	if err != nil {
+		return nil, err
+	}
+	if pollInterval <= 0 {
+		pollInterval = DefaultPollInterval
+	}
+
+	for {
+		task, err := cs.GetTask(ctx, course, taskID)
+		if err != nil {
+			return nil, err
+		}
+
+		cs.client.logger.DebugContext(ctx, "course task", "task", taskID, "status", task.Status, "percent", task.PercentComplete)
+		for _, fn := range progress {
+			fn(*task)
+		}
+
+		if task.Done() {
+			if task.Status != TaskComplete {
+				return task, fmt.Errorf("task %s ended as %q: %w", taskID, task.Status, ErrTaskFailed)
+			}
+			return task, nil
+		}
+
+		if err := sleepCtx(ctx, pollInterval); err != nil {
+			return task, err
+		}
+	}
+}
+
+// parseTaskURI pulls the course and task ID out of a Location header like
+// /learn/api/public/v1/courses/_12_1/tasks/_5_1, with or without the host.
+func parseTaskURI(taskURI string) (CourseRef, string, error) {
+	u, err := url.Parse(strings.TrimSpace(taskURI))
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid task URI %q: %w", taskURI, err)
+	}
+
+	segs := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for i := 0; i+3 < len(segs); i++ {
+		if segs[i] != "courses" || segs[i+2] != "tasks" {
+			continue
+		}
+
+		course, err1 := url.PathUnescape(segs[i+1])
+		task, err2 := url.PathUnescape(segs[i+3])
+		if err1 != nil || err2 != nil || course == "" || task == "" {
+			break
+		}
+		return ByPrimaryID(course), task, nil
+	}
+
+	return nil, "", fmt.Errorf("invalid task URI %q", taskURI)
+}
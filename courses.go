@@ -6,7 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,7 +25,33 @@ type CourseService struct {
 var ErrCourseNotFound = errors.New("course doesn't exist")
 var ErrCourseExist = errors.New("course already exists")
 var ErrUserAlreadyEnrolled = errors.New("user already enrolled in course, use UpdateMembership() if needed")
-var ErrInvalidRole = errors.New("invalid course role")
+var ErrInvalidRole = errors.New("invalid role")
+var ErrInvalidCourseID = errors.New("invalid course ID")
+var ErrMembershipNotFound = errors.New("user isn't enrolled in course")
+
+// MaxCourseIDLength is the longest courseId Learn takes.
+const MaxCourseIDLength = 100
+
+// ValidateCourseID checks a courseId before it's sent to Learn: it can't be
+// empty or longer than MaxCourseIDLength, and may only hold letters, digits,
+// '-', '_' and '.'.
+func ValidateCourseID(courseID string) error {
+	switch {
+	case courseID == "":
+		return fmt.Errorf("%w: it is empty", ErrInvalidCourseID)
+	case len(courseID) > MaxCourseIDLength:
+		return fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidCourseID, courseID, MaxCourseIDLength)
+	}
+
+	for _, r := range courseID {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return fmt.Errorf("%w: %q can't contain %q", ErrInvalidCourseID, courseID, r)
+		}
+	}
+	return nil
+}
 
 type CourseAvailability struct {
 	Available string         `json:"available"`
@@ -93,13 +123,24 @@ type CourseUpdateRequest struct {
 	Description  *string             `json:"description,omitempty"`
 }
 
+// CourseCreateRequest is the body CreatePro sends. CourseID and Name are
+// required; nil fields are left to the server, except Availability and
+// Enrollment which default to what Create uses.
 type CourseCreateRequest struct {
-	Name         *string             `json:"name,omitempty"`
-	ExternalID   *string             `json:"termId,omitempty"`
-	TermID       *string             `json:"externalId,omitempty"`
-	Availability *CourseAvailability `json:"availability,omitempty"`
-	DataSourceID *string             `json:"dataSourceId,omitempty"`
-	Description  *string             `json:"description,omitempty"`
+	CourseID       *string             `json:"courseId,omitempty"`
+	ExternalID     *string             `json:"externalId,omitempty"`
+	Name           *string             `json:"name,omitempty"`
+	Description    *string             `json:"description,omitempty"`
+	TermID         *string             `json:"termId,omitempty"`
+	DataSourceID   *string             `json:"dataSourceId,omitempty"`
+	Organization   *bool               `json:"organization,omitempty"`
+	UltraStatus    *string             `json:"ultraStatus,omitempty"`
+	AllowGuests    *bool               `json:"allowGuests,omitempty"`
+	AllowObservers *bool               `json:"allowObservers,omitempty"`
+	ClosedComplete *bool               `json:"closedComplete,omitempty"`
+	Availability   *CourseAvailability `json:"availability,omitempty"`
+	Enrollment     *Enrollment         `json:"enrollment,omitempty"`
+	Locale         *CourseLocale       `json:"locale,omitempty"`
 }
 
 type EnrollmentRequest struct {
@@ -114,11 +155,20 @@ type MembershipAvailability struct {
 	Available *string `json:"available,omitempty"`
 }
 
+// CourseMembership is a user's enrollment in a course. User is only set
+// when the user was expanded, as GetMembership does.
 type CourseMembership struct {
+	ID            string                  `json:"id,omitempty"`
+	UserID        string                  `json:"userId,omitempty"`
 	CourseID      *string                 `json:"courseId"`
 	ChildCourseID *string                 `json:"childCourseId,omitempty"`
+	DataSourceID  string                  `json:"dataSourceId,omitempty"`
 	CourseRoleID  *string                 `json:"courseRoleId"`
 	Availability  *MembershipAvailability `json:"availability"`
+	DisplayOrder  int                     `json:"displayOrder,omitempty"`
+	Created       *time.Time              `json:"created,omitempty"`
+	Modified      *time.Time              `json:"modified,omitempty"`
+	LastAccessed  *time.Time              `json:"lastAccessed,omitempty"` // nil if never
 	User          *User                   `json:"user"`
 	// User          struct {
 	// 	UserName   string `json:"userName"`
@@ -136,17 +186,28 @@ const (
 	AvailabilityYes      string = "Yes"
 	AvailabilityNo       string = "No"
 	AvailabilityDisabled string = "Disabled"
-
-	RoleStudent    string = "Student"
-	RoleInstructor string = "Instructor"
-	RoleTA         string = "TeachingAssistant"
-
-	//TODO: Find out what the string value is
-	RoleCourseBuilder string = ""
-	RoleFacilitator   string = ""
-	RoleSpectator     string = ""
-	RoleGraderstring  string = ""
-	RoleGuest         string = ""
+	AvailabilityTerm     string = "Term" // courses only, follows the term's availability
+
+	// Course ultraStatus values.
+	UltraStatusUndecided string = "Undecided"
+	UltraStatusClassic   string = "Classic"
+	UltraStatusUltra     string = "Ultra"
+	UltraStatusPreview   string = "UltraPreview"
+
+	// Built in course roles. Custom ones are listed by RoleService.CourseRoles.
+	RoleStudent       string = "Student"
+	RoleInstructor    string = "Instructor"
+	RoleTA            string = "TeachingAssistant"
+	RoleCourseBuilder string = "CourseBuilder"
+	RoleFacilitator   string = "BbFacilitator"
+	RoleGrader        string = "Grader"
+	RoleGuest         string = "Guest"
+
+	// Deprecated: Learn has no built in spectator role. Look for a custom
+	// one with RoleService.CourseRoles.
+	RoleSpectator string = ""
+	// Deprecated: use RoleGrader.
+	RoleGraderstring = RoleGrader
 )
 
 // type UserCourseEnrollment struct {
@@ -163,25 +224,20 @@ type CourseUser struct {
 	CourseRoleID string
 }
 
-type courseUsersResponse struct {
-	Results []struct {
-		ID           string `json:"id"`
-		CourseRoleID string `json:"courseRoleId"`
-		User         struct {
-			UserName string `json:"userName"`
-
-			Name struct {
-				Given  string `json:"given"`
-				Family string `json:"family"`
-			} `json:"name"`
-		} `json:"user"`
-		Availability struct {
-			Available string `json:"available"`
-		} `json:"availability"`
-	} `json:"results"`
-	Paging struct {
-		NextPage string `json:"nextPage"`
-	} `json:"paging"`
+type courseUserResult struct {
+	ID           string `json:"id"`
+	CourseRoleID string `json:"courseRoleId"`
+	User         struct {
+		UserName string `json:"userName"`
+
+		Name struct {
+			Given  string `json:"given"`
+			Family string `json:"family"`
+		} `json:"name"`
+	} `json:"user"`
+	Availability struct {
+		Available string `json:"available"`
+	} `json:"availability"`
 }
 
 // Create function that only needs the bare minimum. For simple creates.
@@ -193,6 +249,9 @@ func (cs *CourseService) Create(ctx context.Context, courseID string, title stri
 	if courseID == "" || title == "" || termID == "" {
 		return nil, errors.New("missing parameters: courseID, title, termID")
 	}
+	if err := ValidateCourseID(courseID); err != nil {
+		return nil, err
+	}
 
 	data := Course{
 		CourseID:     courseID,
@@ -219,63 +278,67 @@ func (cs *CourseService) Create(ctx context.Context, courseID string, title stri
 	}
 	defer resp.Body.Close()
 
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
 	switch resp.StatusCode {
-	case 201:
-		body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
+	case http.StatusCreated:
 		var course Course
 		if err := json.Unmarshal(body, &course); err != nil {
 			return nil, fmt.Errorf("failed to parse course response: %w", err)
 		}
 		return &course, nil
-		//client.Logger.Info(fmt.Sprintf("User %s was created successfully", username))
-	case 403:
-		return nil, ErrInsufficientPrivileges
-		//client.Logger.Error("Insufficient privileges to create a new user")
-	case 409:
-		return nil, ErrCourseExist
-		//client.Logger.Error(fmt.Sprintf("User with ID %s already exists", username))
-	case 400:
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return nil, fmt.Errorf("The request did not specify valid data. %s", string(body))
+	case http.StatusConflict:
+		return nil, newAPIError(resp, body, ErrCourseExist)
 	default:
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body, nil)
 	}
 }
 
-// A more advanced version of Create()
+// CreatePro is Create with every field Learn takes on a new course. The
+// courseId is checked with ValidateCourseID before anything is sent. If
+// CourseID is unset, ExternalID is used as the courseId, as it was before
+// CourseID existed.
 func (cs *CourseService) CreatePro(ctx context.Context, req *CourseCreateRequest) (*Course, error) {
-	courseID := strings.TrimSpace(*req.ExternalID)
-	title := strings.TrimSpace(*req.Name)
-	termID := strings.TrimSpace(*req.TermID)
-	description := strings.TrimSpace(*req.Description)
+	if req == nil {
+		return nil, errors.New("create request is nil")
+	}
+	data := *req
 
-	//TODO: Test if TermID is blank.
-	if courseID == "" || title == "" || termID == "" {
-		return nil, errors.New("missing parameters: courseID, title, termID")
+	courseID := strings.TrimSpace(deref(data.CourseID))
+	if courseID == "" {
+		courseID = strings.TrimSpace(deref(data.ExternalID))
 	}
+	if err := ValidateCourseID(courseID); err != nil {
+		return nil, err
+	}
+	data.CourseID = &courseID
 
-	data := Course{
-		CourseID:     courseID,
-		Name:         title,
-		TermID:       termID,
-		Organization: false,
-		Description:  description,
-		Availability: CourseAvailability{
-			Available: AvailabilityNo,
-			Duration: CourseDuration{
-				Type: "Continuous",
-			},
-		},
-		Enrollment: Enrollment{
-			Type: "InstructorLed",
-		},
+	name := strings.TrimSpace(deref(data.Name))
+	if name == "" {
+		return nil, fmt.Errorf("course name is required: %w", ErrEmptyStringParameter)
 	}
+	data.Name = &name
 
-	//fmt.Printf("%v\n", data)
+	if data.UltraStatus != nil {
+		switch *data.UltraStatus {
+		case UltraStatusUndecided, UltraStatusClassic, UltraStatusUltra, UltraStatusPreview:
+		default:
+			return nil, fmt.Errorf("invalid ultra status %q", *data.UltraStatus)
+		}
+	}
+
+	if data.Availability == nil {
+		data.Availability = &CourseAvailability{
+			Available: AvailabilityNo,
+			Duration:  CourseDuration{Type: "Continuous"},
+		}
+	}
+	if data.Enrollment == nil {
+		data.Enrollment = &Enrollment{Type: "InstructorLed"}
+	}
 
 	url := endpoints.Courses.Create()
 	resp, err := cs.client.Post(ctx, url, data)
@@ -284,34 +347,28 @@ func (cs *CourseService) CreatePro(ctx context.Context, req *CourseCreateRequest
 	}
 	defer resp.Body.Close()
 
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
 	switch resp.StatusCode {
-	case 201:
-		body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
+	case http.StatusCreated:
 		var course Course
 		if err := json.Unmarshal(body, &course); err != nil {
 			return nil, fmt.Errorf("failed to parse course response: %w", err)
 		}
 		return &course, nil
-		//client.Logger.Info(fmt.Sprintf("User %s was created successfully", username))
-	case 403:
-		return nil, ErrInsufficientPrivileges
-		//client.Logger.Error("Insufficient privileges to create a new user")
-	case 409:
-		return nil, ErrCourseExist
-		//client.Logger.Error(fmt.Sprintf("User with ID %s already exists", username))
-	case 400:
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return nil, fmt.Errorf("The request did not specify valid data. %s", string(body))
+	case http.StatusConflict:
+		return nil, newAPIError(resp, body, ErrCourseExist)
 	default:
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body, nil)
 	}
 }
 
-// TODO: Rename parameters
+// CopyCourseByCourseID copies everything in sourceID into a new course
+// destinationID and returns the task URI of the copy. It's CopyCourse with
+// FullCopy.
 func (cs *CourseService) CopyCourseByCourseID(ctx context.Context, sourceID, destinationID string) (string, error) {
 	sourceID = strings.TrimSpace(sourceID)
 	destinationID = strings.TrimSpace(destinationID)
@@ -320,69 +377,15 @@ func (cs *CourseService) CopyCourseByCourseID(ctx context.Context, sourceID, des
 		return "", errors.New("sourceID and destinationID are required")
 	}
 
-	// Build request
-	data := map[string]any{
-		"targetCourse": map[string]string{
-			"courseId": destinationID,
-		},
-		"copy": map[string]any{
-			"adaptiveReleaseRules": true,
-			"announcements":        true,
-			"assessments":          true,
-			"blogs":                true,
-			"calendar":             true,
-			"contacts":             true,
-			"contentAlignments":    true,
-			"contentAreas":         true,
-			"discussions":          "ForumsAndStarterPosts",
-			"glossary":             true,
-			"gradebook":            true,
-			"groupSettings":        true,
-			"journals":             true,
-			"retentionRules":       true,
-			"rubrics":              true,
-			"settings": map[string]bool{
-				"availability":       false,
-				"bannerImage":        true,
-				"duration":           true,
-				"enrollmentOptions":  true,
-				"guestAccess":        true,
-				"languagePack":       true,
-				"navigationSettings": true,
-				"observerAccess":     true,
-			},
-			"tasks": true,
-			"wikis": true,
-		},
-	}
-
-	url := endpoints.Courses.Copy(sourceID)
-	resp, err := cs.client.Post(ctx, url, data)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
-
-	switch resp.StatusCode {
-	case 202:
-		// "Course was successfully created from
-		taskURI := resp.Header.Get("Location")
-		if taskURI == "" {
-			return "", fmt.Errorf("202 Accepted received but Location header was missing")
-		}
-		return taskURI, nil
-	default:
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return "", fmt.Errorf("failed to copy course (status %d): %s", resp.StatusCode, string(body))
-	}
+	return cs.CopyCourse(ctx, ByCourseID(sourceID), destinationID, FullCopy())
 }
 
-func (cs *CourseService) DoesCourseExist(ctx context.Context, courseID string) (bool, error) {
-	courseID = strings.TrimSpace(courseID)
-	if courseID == "" {
-		return false, errors.New("courseID is required")
+func (cs *CourseService) DoesCourseExist(ctx context.Context, course CourseRef) (bool, error) {
+	path, err := coursePath(course)
+	if err != nil {
+		return false, err
 	}
-	url := endpoints.Courses.GetByCourseId(courseID)
+	url := endpoints.Courses.Get(path)
 	resp, err := cs.client.Get(ctx, url)
 	if err != nil {
 		// TODO: This could be better?
@@ -393,126 +396,136 @@ func (cs *CourseService) DoesCourseExist(ctx context.Context, courseID string) (
 	switch resp.StatusCode {
 	case http.StatusOK:
 		return true, nil
-	//TODO: Add other codes
+	case http.StatusNotFound:
+		return false, nil
 	default:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return false, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return false, newAPIError(resp, body, nil)
 	}
 }
 
-func (cs *CourseService) GetCourseByCourseId(ctx context.Context, courseID string) (*Course, error) {
-	courseID = strings.TrimSpace(courseID)
+// Get fetches a single course.
+func (cs *CourseService) Get(ctx context.Context, course CourseRef) (*Course, error) {
+	path, err := coursePath(course)
+	if err != nil {
+		return nil, err
+	}
 
-	url := endpoints.Courses.GetByCourseId(courseID)
+	url := endpoints.Courses.Get(path)
 	resp, err := cs.client.Get(ctx, url)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get user: %d", resp.StatusCode)
-	}
-
 	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
 	if err != nil {
 		return nil, err
 	}
 
-	var course Course
-	if err := json.Unmarshal(body, &course); err != nil {
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, newAPIError(resp, body, ErrCourseNotFound)
+	default:
+		return nil, newAPIError(resp, body, nil)
+	}
+
+	var c Course
+	if err := json.Unmarshal(body, &c); err != nil {
 		return nil, err
 	}
 
-	return &course, nil
+	return &c, nil
+}
+
+func (cs *CourseService) GetCourseByCourseId(ctx context.Context, courseID string) (*Course, error) {
+	return cs.Get(ctx, ByCourseID(courseID))
 }
 
 func (cs *CourseService) GetCourseById(ctx context.Context, id string) (*Course, error) {
-	id = strings.TrimSpace(id)
+	return cs.Get(ctx, ByPrimaryID(id))
+}
 
-	url := endpoints.Courses.GetById(id)
-	resp, err := cs.client.Get(ctx, url)
+func (cs *CourseService) AddChildCourse(ctx context.Context, course CourseRef, child CourseRef) error {
+	parentPath, err := coursePath(course)
 	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get user: %d", resp.StatusCode)
+		return err
 	}
-
-	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+	childPath, err := coursePath(child)
 	if err != nil {
-		return nil, err
-	}
-
-	var course Course
-	if err := json.Unmarshal(body, &course); err != nil {
-		return nil, err
+		return err
 	}
 
-	return &course, nil
-}
-
-func (cs *CourseService) AddChildCourse(ctx context.Context, courseID string, childID string) error {
-	url := endpoints.Courses.AddChildCourse(courseID, childID)
+	url := endpoints.Courses.AddChildCourse(parentPath, childPath)
 
 	resp, err := cs.client.Put(ctx, url, nil)
 	if err != nil {
-		return fmt.Errorf("request failed adding child course %s to %s: %w", childID, courseID, err)
+		return fmt.Errorf("request failed adding child course %s to %s: %w", child, course, err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated, http.StatusNoContent:
 		return nil
+	case http.StatusNotFound:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, ErrCourseNotFound)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, nil)
 	}
-
-	body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-	return fmt.Errorf("failed to add child course %s to %s (HTTP %d - %s)", childID, courseID, resp.StatusCode, string(body))
 }
 
 // Tested 11/4/25
-func (cs *CourseService) DeleteCourse(ctx context.Context, courseID string) error {
-	url := endpoints.Courses.GetByCourseId(courseID)
+func (cs *CourseService) DeleteCourse(ctx context.Context, course CourseRef) error {
+	path, err := coursePath(course)
+	if err != nil {
+		return err
+	}
+
+	url := endpoints.Courses.Get(path)
 	resp, err := cs.client.Delete(ctx, url)
 	if err != nil {
-		return fmt.Errorf("request failed deleting course %s: %v", courseID, err)
+		return fmt.Errorf("request failed deleting course %s: %v", course, err)
 	}
 	defer resp.Body.Close()
 
 	//TODO: I think I can remove status accepted, need to check docs
-	if resp.StatusCode == http.StatusAccepted || resp.StatusCode == http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNoContent:
 		return nil
+	case http.StatusNotFound:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, ErrCourseNotFound)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, nil)
 	}
-
-	body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-	return fmt.Errorf("failed to delete course %s (HTTP code %d) %s", courseID, resp.StatusCode, string(body))
 }
 
-func (cs *CourseService) CreateMembership(ctx context.Context, username, courseID string, update EnrollmentRequest) error {
-	return cs.upsertMembership(ctx, "PUT", username, courseID, update)
+func (cs *CourseService) CreateMembership(ctx context.Context, user UserRef, course CourseRef, update EnrollmentRequest) error {
+	return cs.upsertMembership(ctx, "PUT", user, course, update)
 }
 
-func (cs *CourseService) GetMembership(ctx context.Context, username string, courseID string) (*CourseMembership, error) {
-	return nil, errors.New("GetMembership not implemented")
-}
-
-func (cs *CourseService) UpdateMembership(ctx context.Context, username, courseID string, update EnrollmentRequest) error {
-	return cs.upsertMembership(ctx, "PATCH", username, courseID, update)
+func (cs *CourseService) UpdateMembership(ctx context.Context, user UserRef, course CourseRef, update EnrollmentRequest) error {
+	return cs.upsertMembership(ctx, "PATCH", user, course, update)
 }
 
 // TODO: Create and update are the same, other than put or patch. Could this be better?
-func (cs *CourseService) upsertMembership(ctx context.Context, method string, username string, courseID string, update EnrollmentRequest) error {
+func (cs *CourseService) upsertMembership(ctx context.Context, method string, user UserRef, course CourseRef, update EnrollmentRequest) error {
 	//TODO: Add course not found, user already enrolled, blah blah blah...
-	username = strings.TrimSpace(username)
-	courseID = strings.TrimSpace(courseID)
-	if username == "" || courseID == "" {
-		return ErrEmptyStringParameter
+	uPath, err := userPath(user)
+	if err != nil {
+		return err
+	}
+	cPath, err := coursePath(course)
+	if err != nil {
+		return err
 	}
 
-	url := endpoints.Courses.CreateMembership(courseID, username)
+	url := endpoints.Courses.CreateMembership(cPath, uPath)
 	var resp *http.Response
-	var err error
 
 	switch method {
 	case "PUT":
@@ -535,32 +548,22 @@ func (cs *CourseService) upsertMembership(ctx context.Context, method string, us
 	case http.StatusNotFound:
 		// TODO: This can be User does not exist; or Role does not exist
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf("enrolling user failed: %s", string(body))
-
-	case 409:
-		return ErrUserAlreadyEnrolled
+		return newAPIError(resp, body, nil)
 
-	case http.StatusBadRequest, http.StatusInternalServerError:
+	case http.StatusConflict:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf("invalid user update: %s", string(body))
+		return newAPIError(resp, body, ErrUserAlreadyEnrolled)
 
 	default:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf(
-			"update user failed: status=%d body=%s",
-			resp.StatusCode,
-			string(body),
-		)
+		return newAPIError(resp, body, nil)
 	}
 }
 
-func (cs *CourseService) UpdateMembershipAvailability(ctx context.Context, username string, courseID string, availability string) error {
-	//TODO: Add course not found, user already enrolled, blah blah blah...
-	username = strings.TrimSpace(username)
-	courseID = strings.TrimSpace(courseID)
+func (cs *CourseService) UpdateMembershipAvailability(ctx context.Context, user UserRef, course CourseRef, availability string) error {
 	availability = strings.TrimSpace(availability)
-	if username == "" || courseID == "" || availability == "" {
-		return ErrInvalidUsername
+	if availability == "" {
+		return fmt.Errorf("availability is required: %w", ErrEmptyStringParameter)
 	}
 
 	updateReq := EnrollmentRequest{
@@ -569,14 +572,14 @@ func (cs *CourseService) UpdateMembershipAvailability(ctx context.Context, usern
 		},
 	}
 
-	return cs.UpdateMembership(ctx, username, courseID, updateReq)
+	return cs.UpdateMembership(ctx, user, course, updateReq)
 
 }
 
-func (cs *CourseService) Update(ctx context.Context, courseID string, req *CourseUpdateRequest) (*Course, error) {
-	courseID = strings.TrimSpace(courseID)
-	if courseID == "" {
-		return nil, errors.New("courseID is required")
+func (cs *CourseService) Update(ctx context.Context, course CourseRef, req *CourseUpdateRequest) (*Course, error) {
+	path, err := coursePath(course)
+	if err != nil {
+		return nil, err
 	}
 
 	// Validate at least one field is being updated
@@ -602,7 +605,7 @@ func (cs *CourseService) Update(ctx context.Context, courseID string, req *Cours
 		req.Description = &trimmed
 	}
 
-	url := endpoints.Courses.Update(courseID)
+	url := endpoints.Courses.Update(path)
 	resp, err := cs.client.Patch(ctx, url, req)
 	if err != nil {
 		return nil, err
@@ -616,43 +619,46 @@ func (cs *CourseService) Update(ctx context.Context, courseID string, req *Cours
 			return nil, fmt.Errorf("failed to decode updated course: %w", err)
 		}
 		return &updated, nil
-
-	case 404:
-		return nil, fmt.Errorf("course %s not found", courseID)
-	case 403:
-		return nil, ErrInsufficientPrivileges
-	case 400:
+	case http.StatusNotFound:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return nil, fmt.Errorf("bad request: %s", string(body))
+		return nil, newAPIError(resp, body, ErrCourseNotFound)
 	default:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return nil, newAPIError(resp, body, nil)
 	}
 
 }
 
 // EnrollUserIntoCourse is a wrapper function that calls CreateMembership.
-func (cs *CourseService) EnrollUserIntoCourse(ctx context.Context, courseID string, username string, role string, availability string) error {
+// role is checked against the server's course roles first (see RoleService)
+// and a *RoleError is returned if it isn't one.
+func (cs *CourseService) EnrollUserIntoCourse(ctx context.Context, course CourseRef, user UserRef, role string, availability string) error {
+	if err := cs.client.Roles.ValidateCourseRole(ctx, role); err != nil {
+		return err
+	}
+
 	updateReq := EnrollmentRequest{
 		CourseRoleID: ToPtr(role),
 		Availability: &MembershipAvailability{
 			Available: ToPtr(availability),
 		},
 	}
-	return cs.CreateMembership(ctx, username, courseID, updateReq)
+	return cs.CreateMembership(ctx, user, course, updateReq)
 }
 
 // RemoveUser will remove a user from a course.
-func (cs *CourseService) RemoveUser(ctx context.Context, courseID string, username string) error {
-	username = strings.TrimSpace(username)
-	courseID = strings.TrimSpace(courseID)
-	if username == "" || courseID == "" {
-		return ErrEmptyStringParameter
+func (cs *CourseService) RemoveUser(ctx context.Context, course CourseRef, user UserRef) error {
+	uPath, err := userPath(user)
+	if err != nil {
+		return err
+	}
+	cPath, err := coursePath(course)
+	if err != nil {
+		return err
 	}
 
-	url := endpoints.Courses.DeleteMembership(courseID, username)
+	url := endpoints.Courses.DeleteMembership(cPath, uPath)
 	var resp *http.Response
-	var err error
 
 	resp, err = cs.client.Delete(ctx, url)
 
@@ -666,59 +672,194 @@ func (cs *CourseService) RemoveUser(ctx context.Context, courseID string, userna
 
 	} else {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf(
-			"deleting membership failed: status=%d body=%s",
-			resp.StatusCode,
-			string(body),
-		)
+		return newAPIError(resp, body, nil)
 	}
 }
 
 //TODO: Re-evaluate if the grade stuff needs to be its own thing
 
-func (cs *CourseService) GetUsers(ctx context.Context, courseID string) ([]CourseUser, error) {
-	//TODO: Move to endpoint file
-	path := fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/users?expand=user&fields=id,courseRoleId,user.userName,availability.available,user.name.given,user.name.family", courseID)
+func (cs *CourseService) GetUsers(ctx context.Context, course CourseRef) ([]CourseUser, error) {
+	path, err := coursePath(course)
+	if err != nil {
+		return nil, err
+	}
+
+	url := endpoints.Courses.GetUsers(path) + "?expand=user&fields=id,courseRoleId,user.userName,availability.available,user.name.given,user.name.family"
+
+	pager := NewPager[courseUserResult](cs.client, url)
+	pager.NotFound = ErrCourseNotFound
 
 	var allUsers []CourseUser
 
-	for {
-		resp, err := cs.client.Get(ctx, path)
+	for r, err := range pager.All(ctx) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to get course users: %w", err)
 		}
-		defer resp.Body.Close()
 
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
+		allUsers = append(allUsers, CourseUser{
+			ID:           r.ID,
+			UserName:     r.User.UserName,
+			FirstName:    r.User.Name.Given,
+			LastName:     r.User.Name.Family,
+			Available:    r.Availability.Available,
+			CourseRoleID: r.CourseRoleID,
+		})
+	}
+
+	return allUsers, nil
+}
+
+// CourseQuery filters CourseService.List. CourseID, Name and ExternalID
+// match anywhere in the field, ignoring case. Zero values are left out.
+type CourseQuery struct {
+	CourseID   string
+	Name       string
+	ExternalID string
+
+	TermID       string
+	DataSourceID string
+
+	// As with UserQuery, After bounds are inclusive and Before bounds
+	// exclusive. The After bounds are sent to Learn and the Before bounds
+	// are checked here, unless only a Before bound is set.
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	// Organization picks organizations (true) or courses (false). nil for both.
+	Organization *bool
+	Available    string // AvailabilityYes, AvailabilityNo, AvailabilityDisabled or AvailabilityTerm
+	UltraStatus  string // one of the UltraStatus constants
+
+	// Sort is a field name, with "(desc)" for descending, e.g. "courseId(desc)".
+	Sort string
+	// Fields limits the fields returned for each course.
+	Fields []string
+
+	PageSize int // results per request, 0 for the server default
+	MaxItems int // stop after this many courses, 0 for all
+}
+
+func (q CourseQuery) validate() error {
+	switch q.Available {
+	case "", AvailabilityYes, AvailabilityNo, AvailabilityDisabled, AvailabilityTerm:
+	default:
+		return fmt.Errorf("invalid availability %q", q.Available)
+	}
+
+	switch q.UltraStatus {
+	case "", UltraStatusUndecided, UltraStatusClassic, UltraStatusUltra, UltraStatusPreview:
+	default:
+		return fmt.Errorf("invalid ultra status %q", q.UltraStatus)
+	}
+
+	if !q.CreatedAfter.IsZero() && !q.CreatedBefore.IsZero() && q.CreatedAfter.After(q.CreatedBefore) {
+		return errors.New("CreatedAfter is after CreatedBefore")
+	}
+	if !q.ModifiedAfter.IsZero() && !q.ModifiedBefore.IsZero() && q.ModifiedAfter.After(q.ModifiedBefore) {
+		return errors.New("ModifiedAfter is after ModifiedBefore")
+	}
+	return nil
+}
+
+// path builds the first page's path. It also returns the checks that
+// Learn can't do itself.
+func (q CourseQuery) path() (string, func(Course) bool) {
+	v := url.Values{}
+
+	text := map[string]string{
+		"courseId":               q.CourseID,
+		"name":                   q.Name,
+		"externalId":             q.ExternalID,
+		"termId":                 q.TermID,
+		"dataSourceId":           q.DataSourceID,
+		"availability.available": q.Available,
+		"ultraStatus":            q.UltraStatus,
+		"sort":                   q.Sort,
+	}
+	for k, val := range text {
+		if val = strings.TrimSpace(val); val != "" {
+			v.Set(k, val)
 		}
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	if q.Organization != nil {
+		v.Set("organization", strconv.FormatBool(*q.Organization))
+	}
+	if q.PageSize > 0 {
+		v.Set("limit", strconv.Itoa(q.PageSize))
+	}
+
+	created := dateBound{"created", q.CreatedAfter, q.CreatedBefore}
+	modified := dateBound{"modified", q.ModifiedAfter, q.ModifiedBefore}
+	checkCreated, checkModified := created.server(v), modified.server(v)
+
+	if len(q.Fields) > 0 {
+		fields := slices.Clone(q.Fields)
+		if checkCreated && !slices.Contains(fields, "created") {
+			fields = append(fields, "created")
+		}
+		if checkModified && !slices.Contains(fields, "modified") {
+			fields = append(fields, "modified")
 		}
+		v.Set("fields", strings.Join(fields, ","))
+	}
 
-		var result courseUsersResponse
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
+	keep := func(c Course) bool {
+		if checkCreated && !created.match(c.Created) {
+			return false
+		}
+		if checkModified && !modified.match(c.Modified) {
+			return false
 		}
+		return true
+	}
 
-		for _, r := range result.Results {
-			allUsers = append(allUsers, CourseUser{
-				ID:           r.ID,
-				UserName:     r.User.UserName,
-				FirstName:    r.User.Name.Given,
-				LastName:     r.User.Name.Family,
-				Available:    r.Availability.Available,
-				CourseRoleID: r.CourseRoleID,
-			})
+	path := endpoints.Courses.List()
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	return path, keep
+}
+
+// List yields every course matching q, fetching pages only as the loop asks
+// for them.
+//
+//	for c, err := range client.Courses.List(ctx, chawk.CourseQuery{TermID: "2026FA"}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(c.CourseID, c.Name)
+//	}
+func (cs *CourseService) List(ctx context.Context, q CourseQuery) iter.Seq2[Course, error] {
+	return func(yield func(Course, error) bool) {
+		if err := q.validate(); err != nil {
+			yield(Course{}, err)
+			return
 		}
 
-		if result.Paging.NextPage == "" {
-			break
+		path, keep := q.path()
+		pager := NewPager[Course](cs.client, path)
+
+		matched := 0
+		for c, err := range pager.All(ctx) {
+			if err != nil {
+				yield(Course{}, fmt.Errorf("failed to list courses: %w", err))
+				return
+			}
+			if !keep(c) {
+				continue
+			}
+
+			if !yield(c, nil) {
+				return
+			}
+
+			matched++
+			if q.MaxItems > 0 && matched >= q.MaxItems {
+				return
+			}
 		}
-		path = result.Paging.NextPage
 	}
-
-	return allUsers, nil
 }
@@ -0,0 +1,103 @@
+package chawk_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func TestCourseQueryString(t *testing.T) {
+	day := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		q    chawk.CourseQuery
+		want string
+	}{
+		{
+			name: "after",
+			q:    chawk.CourseQuery{CreatedAfter: day},
+			want: "created=2025-07-01T00%3A00%3A00Z&createdCompare=greaterOrEqual",
+		},
+		{
+			name: "before",
+			q:    chawk.CourseQuery{ModifiedBefore: day},
+			want: "modified=2025-07-01T00%3A00%3A00Z&modifiedCompare=lessThan",
+		},
+		{
+			name: "both sends after",
+			q:    chawk.CourseQuery{ModifiedAfter: day, ModifiedBefore: day.AddDate(0, 1, 0), Fields: []string{"courseId"}},
+			want: "fields=courseId%2Cmodified&modified=2025-07-01T00%3A00%3A00Z&modifiedCompare=greaterOrEqual",
+		},
+		{
+			name: "filters",
+			q:    chawk.CourseQuery{TermID: "2025FA", Organization: chawk.ToPtr(false), Sort: "courseId(desc)"},
+			want: "organization=false&sort=courseId%28desc%29&termId=2025FA",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t)
+			for _, err := range client.Courses.List(context.Background(), tt.q) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := lastQuery(t, srv, "/learn/api/public/v3/courses"); got != tt.want {
+				t.Errorf("query\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCourseListDates(t *testing.T) {
+	srv, client := newTestServer(t)
+	t0 := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	for id, created := range map[string]time.Time{
+		"EARLY": t0.Add(-time.Hour),
+		"EXACT": t0,
+		"LATE":  t0.Add(time.Hour),
+	} {
+		srv.AddCourse(chawk.Course{CourseID: id, Name: id, Created: &created})
+	}
+
+	tests := []struct {
+		name string
+		q    chawk.CourseQuery
+		want []string
+	}{
+		{name: "before is exclusive", q: chawk.CourseQuery{CreatedBefore: t0}, want: []string{"EARLY"}},
+		{name: "after is inclusive", q: chawk.CourseQuery{CreatedAfter: t0}, want: []string{"EXACT", "LATE"}},
+		{name: "range", q: chawk.CourseQuery{CreatedAfter: t0.Add(-time.Hour), CreatedBefore: t0.Add(time.Hour)}, want: []string{"EARLY", "EXACT"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for c, err := range client.Courses.List(context.Background(), tt.q) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, c.CourseID)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	resp, err := client.Get(context.Background(), "/learn/api/public/v3/courses?modified=2025-07-01T00:00:00Z&modifiedCompare=lessOrEqual")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("modifiedCompare=lessOrEqual: status %d, want 400", resp.StatusCode)
+	}
+}
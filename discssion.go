@@ -2,16 +2,15 @@ package chawk
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 
 	endpoints "github.com/sugarvoid/chawk/endpoints"
 )
 
-//TODO: remove testing print staments
-
 type DiscussionService struct {
 	client *BlackboardClient
 }
@@ -73,145 +72,90 @@ type message struct {
 // 	return allDiscussions, nil
 // }
 
-func (d *DiscussionService) getDiscussions(ctx context.Context, courseID string) ([]discussion, error) {
-	url := endpoints.Discussions.GetAll(courseID)
-
-	var allDiscussions []discussion
-
-	for {
-		resp, err := d.client.Get(ctx, url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get discussions: %w", err)
-		}
-
-		body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		resp.Body.Close()
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-		}
-
-		var result struct {
-			Results []discussion `json:"results"`
-			Paging  struct {
-				NextPage string `json:"nextPage"`
-			} `json:"paging"`
-		}
-
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
+func (d *DiscussionService) getDiscussions(ctx context.Context, course string) ([]discussion, error) {
+	pager := NewPager[discussion](d.client, endpoints.Discussions.GetAll(course))
+	pager.NotFound = ErrCourseNotFound
 
-		allDiscussions = append(allDiscussions, result.Results...)
-
-		if result.Paging.NextPage == "" {
-			break
-		}
-		url = result.Paging.NextPage
+	discussions, err := collect(pager.All(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discussions: %w", err)
 	}
 
-	return allDiscussions, nil
+	return discussions, nil
 }
 
-func (d *DiscussionService) getMessages(ctx context.Context, courseID, forumID string) ([]message, error) {
-	url := endpoints.Discussions.GetMessages(courseID, forumID)
-
-	var allMessages []message
-
-	for {
-		resp, err := d.client.Get(ctx, url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get messages: %w", err)
-		}
-
-		body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		resp.Body.Close()
-
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-		}
-
-		var result struct {
-			Results []message `json:"results"`
-			Paging  struct {
-				NextPage string `json:"nextPage"`
-			} `json:"paging"`
-		}
+func (d *DiscussionService) getMessages(ctx context.Context, course, forumID string) ([]message, error) {
+	pager := NewPager[message](d.client, endpoints.Discussions.GetMessages(course, forumID))
+	pager.NotFound = ErrCourseNotFound
 
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
-
-		allMessages = append(allMessages, result.Results...)
-
-		if result.Paging.NextPage == "" {
-			break
-		}
-		url = result.Paging.NextPage
+	messages, err := collect(pager.All(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get messages: %w", err)
 	}
 
-	return allMessages, nil
+	return messages, nil
 }
 
 // ClearDiscussionStudentReplies deletes all posts from users with a given role.
 // TODO: TEST THIS! MIGHT WORK!
-func (d *DiscussionService) ClearStudentReplies(ctx context.Context, courseID, role string) error {
-	courseID, err := RequiredString(courseID, "courseID")
+func (d *DiscussionService) ClearStudentReplies(ctx context.Context, course CourseRef, role string) error {
+	path, err := coursePath(course)
 	if err != nil {
 		return err
 	}
 
 	if role == "" {
-		role = "Student"
+		role = RoleStudent
 	}
 
-	forums, err := d.getDiscussions(ctx, courseID)
+	forums, err := d.getDiscussions(ctx, path)
 	if err != nil {
 		return fmt.Errorf("failed to get discussion IDs: %w", err)
 	}
 
+	// Failures on single posts don't stop the run, they are collected and
+	// returned together at the end.
+	var errs []error
+	// Whether each author has role, so it's looked up once per author.
+	hasRole := map[string]bool{}
+
 	for _, forum := range forums {
-		messages, err := d.getMessages(ctx, courseID, forum.ID)
+		messages, err := d.getMessages(ctx, path, forum.ID)
 		if err != nil {
 			return fmt.Errorf("failed to get messages for forum %s: %w", forum.ID, err)
 		}
 
 		for _, msg := range messages {
-			username, err := d.client.Users.GetUserByUsername(ctx, msg.Author)
-			if err != nil {
-				// TODO: Alert me of fails, but keep going
-				fmt.Errorf("failed to get username for user %s: %v", msg.Author, err)
-				continue
-			}
-
-			courseMem, err := d.client.Courses.GetMembership(ctx, msg.Author, courseID)
-			if err != nil {
-				// TODO: Alert me of fails, but keep going
-				fmt.Errorf("failed to get course role for user %s: %v", username, err)
-				continue
+			match, ok := hasRole[msg.Author]
+			if !ok {
+				m, err := d.client.Courses.GetMembership(ctx, ByPrimaryID(msg.Author), course)
+				switch {
+				case errors.Is(err, ErrMembershipNotFound):
+					// Not in the course any more, so not in the role either.
+				case err != nil:
+					d.client.logger.WarnContext(ctx, "failed to get course role", "course", course, "user", msg.Author, "error", err)
+					errs = append(errs, fmt.Errorf("failed to get course role for user %s: %w", msg.Author, err))
+					continue
+				default:
+					match = m.CourseRoleID != nil && strings.EqualFold(*m.CourseRoleID, role)
+				}
+				hasRole[msg.Author] = match
 			}
 
-			if *courseMem.CourseRoleID == "student" {
-				if err := d.deletePost(ctx, courseID, forum.ID, msg.ID); err != nil {
-					fmt.Errorf("failed to delete post %s: %v", msg.ID, err)
+			if match {
+				if err := d.deletePost(ctx, path, forum.ID, msg.ID); err != nil {
+					d.client.logger.WarnContext(ctx, "failed to delete post", "course", course, "message", msg.ID, "error", err)
+					errs = append(errs, fmt.Errorf("failed to delete post %s: %w", msg.ID, err))
 				}
 			}
 		}
 	}
 
-	return nil
+	return errors.Join(errs...)
 }
 
-func (d *DiscussionService) deletePost(ctx context.Context, courseID, forumID, messageID string) error {
-	url := endpoints.Discussions.DeleteMessage(courseID, forumID, messageID)
+func (d *DiscussionService) deletePost(ctx context.Context, course, forumID, messageID string) error {
+	url := endpoints.Discussions.DeleteMessage(course, forumID, messageID)
 
 	resp, err := d.client.Delete(ctx, url)
 	if err != nil {
@@ -225,10 +169,12 @@ func (d *DiscussionService) deletePost(ctx context.Context, courseID, forumID, m
 		return fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+	default:
+		return newAPIError(resp, body, nil)
 	}
 
-	fmt.Printf("%s message %s deleted.", courseID, messageID)
+	d.client.logger.InfoContext(ctx, "discussion message deleted", "course", course, "forum", forumID, "message", messageID)
 	return nil
 }
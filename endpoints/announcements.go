@@ -10,15 +10,29 @@ func (announcementEndpoints) Create() string {
 	return "/learn/api/public/v3/courses"
 }
 
+func (announcementEndpoints) GetAll(course string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/announcements", course)
+}
+
+func (announcementEndpoints) Get(course, announcementID string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/announcements/%s", course, announcementID)
+}
+
+func (announcementEndpoints) Delete(course, announcementID string) string {
+	return Announcements.Get(course, announcementID)
+}
+
+// Deprecated: use Announcements.GetAll("courseId:" + courseID).
 func (announcementEndpoints) GetAllByCourseId(courseID string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/announcements", courseID)
+	return Announcements.GetAll("courseId:" + courseID)
 }
 
+// Deprecated: use Announcements.Get("courseId:"+courseID, announcementID).
 func (announcementEndpoints) GetSingleById(courseID, announcementID string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/announcements/%s", courseID, announcementID)
+	return Announcements.Get("courseId:"+courseID, announcementID)
 }
 
+// Deprecated: use Announcements.Delete("courseId:"+courseID, announcementID).
 func (announcementEndpoints) DeleteById(courseID, announcementID string) string {
-	return Announcements.GetSingleById(courseID, announcementID)
-	//return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/announcements/%s", courseID, announcementID)
+	return Announcements.Delete("courseId:"+courseID, announcementID)
 }
@@ -5,3 +5,7 @@ import "fmt"
 func GetToken() string {
 	return fmt.Sprintf("/learn/api/public/v1/oauth2/token")
 }
+
+func AuthorizationCode() string {
+	return "/learn/api/public/v1/oauth2/authorizationcode"
+}
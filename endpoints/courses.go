@@ -2,6 +2,10 @@ package endpoints
 
 import "fmt"
 
+// The course and user parameters in this package are path segments, as
+// returned by chawk's CourseRef.PathSegment and UserRef.PathSegment, e.g.
+// "courseId:BIO-101", "userName:jdoe" or "_123_1".
+
 type courseEndpoints struct{}
 
 var Courses = courseEndpoints{}
@@ -10,51 +14,67 @@ func (courseEndpoints) Create() string {
 	return "/learn/api/public/v3/courses"
 }
 
-func (courseEndpoints) Update(courseID string) string {
-	return Courses.GetByCourseId(courseID)
+func (courseEndpoints) List() string {
+	return "/learn/api/public/v3/courses"
 }
 
-func (courseEndpoints) Copy(courseID string) string {
-	return fmt.Sprintf("/learn/api/public/v2/courses/courseId:%s/copy", courseID)
+func (courseEndpoints) Update(course string) string {
+	return Courses.Get(course)
 }
 
-func (courseEndpoints) GetByCourseId(courseID string) string {
-	return fmt.Sprintf("/learn/api/public/v3/courses/courseId:%s", courseID)
+func (courseEndpoints) Copy(course string) string {
+	return fmt.Sprintf("/learn/api/public/v2/courses/%s/copy", course)
 }
 
-func (courseEndpoints) GetTask(courseID string, uri string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/tasks/%s", courseID, uri)
+func (courseEndpoints) Get(course string) string {
+	return fmt.Sprintf("/learn/api/public/v3/courses/%s", course)
 }
 
-func (courseEndpoints) GetById(id string) string {
-	return fmt.Sprintf("/learn/api/public/v3/courses/%s", id)
+func (courseEndpoints) ListTasks(course string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/tasks", course)
 }
 
-func GetUsers(courseID string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/users", courseID)
+func (courseEndpoints) GetTask(course string, task string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/tasks/%s", course, task)
+}
+
+func (courseEndpoints) GetUsers(course string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/users", course)
+}
+
+func (courseEndpoints) AddChildCourse(course string, child string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/children/%s", course, child)
+}
+
+func (courseEndpoints) GetMembership(course string, user string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/users/%s", course, user)
+}
+
+func (courseEndpoints) GetContent(course string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/contents", course)
 }
 
-func (courseEndpoints) AddChildCourse(courseID string, childId string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/children/courseId:%s", courseID, childId)
+func (courseEndpoints) CreateMembership(course string, user string) string {
+	return Courses.GetMembership(course, user)
 }
 
-//TODO: Right now, only accepts courseID and username. Add a way to do this with system ids
-func (courseEndpoints) GetMembership(courseID string, username string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/users/userName:%s", courseID, username)
+func (courseEndpoints) DeleteMembership(course string, user string) string {
+	return Courses.GetMembership(course, user)
 }
 
-func (courseEndpoints) GetContent(courseID string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/contents", courseID)
+// Deprecated: use Courses.Get("courseId:" + courseID).
+func (courseEndpoints) GetByCourseId(courseID string) string {
+	return Courses.Get("courseId:" + courseID)
 }
 
-func (courseEndpoints) CreateMembership(courseID string, username string) string {
-	return Courses.GetMembership(courseID, username)
-	//return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/users/userName:%s", courseId, username)
+// Deprecated: use Courses.Get.
+func (courseEndpoints) GetById(id string) string {
+	return Courses.Get(id)
 }
 
-func (courseEndpoints) DeleteMembership(courseID string, username string) string {
-	return Courses.GetMembership(courseID, username)
-	//return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/users/userName:%s", courseId, username)
+// Deprecated: use Courses.GetUsers("courseId:" + courseID).
+func GetUsers(courseID string) string {
+	return Courses.GetUsers("courseId:" + courseID)
 }
 
 //TODO: Add the rest
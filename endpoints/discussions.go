@@ -6,14 +6,14 @@ type discussionEndpoints struct{}
 
 var Discussions = discussionEndpoints{}
 
-func (discussionEndpoints) GetAll(courseID string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/discussions/", courseID)
+func (discussionEndpoints) GetAll(course string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/discussions/", course)
 }
 
-func (discussionEndpoints) GetMessages(courseID, forumID string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/discussions/%s/messages/", courseID, forumID)
+func (discussionEndpoints) GetMessages(course, forumID string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/discussions/%s/messages/", course, forumID)
 }
 
-func (discussionEndpoints) DeleteMessage(courseID, forumID, messageID string) string {
-	return fmt.Sprintf("/learn/api/public/v1/courses/courseId:%s/discussions/%s/messages/%s", courseID, forumID, messageID)
+func (discussionEndpoints) DeleteMessage(course, forumID, messageID string) string {
+	return fmt.Sprintf("/learn/api/public/v1/courses/%s/discussions/%s/messages/%s", course, forumID, messageID)
 }
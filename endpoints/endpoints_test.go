@@ -0,0 +1,27 @@
+package endpoints
+
+import "testing"
+
+// The deprecated builders take bare IDs, as they did before the ref based
+// ones, and must keep building the same paths.
+func TestDeprecated(t *testing.T) {
+	tests := []struct {
+		got, want string
+	}{
+		{Users.GetByUsername("jdoe"), "/learn/api/public/v1/users/userName:jdoe"},
+		{Users.GetId("jdoe"), "/learn/api/public/v1/users/userName:jdoe"},
+		{Users.Delete(), "/learn/api/public/v1/users"},
+		{Courses.GetByCourseId("BIO-101"), "/learn/api/public/v3/courses/courseId:BIO-101"},
+		{Courses.GetById("_12_1"), "/learn/api/public/v3/courses/_12_1"},
+		{GetUsers("BIO-101"), "/learn/api/public/v1/courses/courseId:BIO-101/users"},
+		{Announcements.GetAllByCourseId("BIO-101"), "/learn/api/public/v1/courses/courseId:BIO-101/announcements"},
+		{Announcements.GetSingleById("BIO-101", "_5_1"), "/learn/api/public/v1/courses/courseId:BIO-101/announcements/_5_1"},
+		{Announcements.DeleteById("BIO-101", "_5_1"), "/learn/api/public/v1/courses/courseId:BIO-101/announcements/_5_1"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %s, want %s", tt.got, tt.want)
+		}
+	}
+}
@@ -6,14 +6,14 @@ type gradeEndpoints struct{}
 
 var Gradebook = gradeEndpoints{}
 
-func (gradeEndpoints) GetColumns(courseID string) string {
-	return fmt.Sprintf("/learn/api/public/v2/courses/courseId:%s/gradebook/columns", courseID)
+func (gradeEndpoints) GetColumns(course string) string {
+	return fmt.Sprintf("/learn/api/public/v2/courses/%s/gradebook/columns", course)
 }
 
-func (gradeEndpoints) GetColumn(courseID, columnID string) string {
-	return fmt.Sprintf("/learn/api/public/v2/courses/courseId:%s/gradebook/columns/%s", courseID, columnID)
+func (gradeEndpoints) GetColumn(course, columnID string) string {
+	return fmt.Sprintf("/learn/api/public/v2/courses/%s/gradebook/columns/%s", course, columnID)
 }
 
-func (gradeEndpoints) CreateColumn(courseID string) string {
-	return Gradebook.GetColumns(courseID)
+func (gradeEndpoints) CreateColumn(course string) string {
+	return Gradebook.GetColumns(course)
 }
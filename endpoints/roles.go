@@ -0,0 +1,17 @@
+package endpoints
+
+type roleEndpoints struct{}
+
+var Roles = roleEndpoints{}
+
+func (roleEndpoints) CourseRoles() string {
+	return "/learn/api/public/v1/courseRoles"
+}
+
+func (roleEndpoints) InstitutionRoles() string {
+	return "/learn/api/public/v1/institutionRoles"
+}
+
+func (roleEndpoints) SystemRoles() string {
+	return "/learn/api/public/v1/systemRoles"
+}
@@ -0,0 +1,9 @@
+package endpoints
+
+type uploadEndpoints struct{}
+
+var Uploads = uploadEndpoints{}
+
+func (uploadEndpoints) Create() string {
+	return "/learn/api/public/v1/uploads"
+}
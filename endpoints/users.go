@@ -10,19 +10,41 @@ func (userEndpoints) Create() string {
 	return "/learn/api/public/v1/users"
 }
 
-func (userEndpoints) Delete() string {
+func (userEndpoints) List() string {
 	return "/learn/api/public/v1/users"
 }
 
-func (userEndpoints) GetByUsername(username string) string {
-	return fmt.Sprintf("/learn/api/public/v1/users/userName:%s", username)
+func (userEndpoints) Get(user string) string {
+	return fmt.Sprintf("/learn/api/public/v1/users/%s", user)
 }
 
-func (userEndpoints) GetId(username string) string {
-	return fmt.Sprintf("/learn/api/public/v1/users/userName:%s", username)
+func (userEndpoints) GetMemberships(user string) string {
+	return fmt.Sprintf("/learn/api/public/v1/users/%s/courses?expand=course&fields=courseId,courseRoleId,created,course.externalId,course.name,course.allowObservers,availability.available", user)
+}
+
+func (userEndpoints) Observers(user string) string {
+	return fmt.Sprintf("/learn/api/public/v1/users/%s/observers", user)
+}
+
+func (userEndpoints) Observer(user, observer string) string {
+	return fmt.Sprintf("/learn/api/public/v1/users/%s/observers/%s", user, observer)
+}
+
+func (userEndpoints) Observees(user string) string {
+	return fmt.Sprintf("/learn/api/public/v1/users/%s/observees", user)
+}
+
+// Deprecated: use Users.List.
+func (userEndpoints) Delete() string {
+	return Users.List()
 }
 
-func (userEndpoints) GetMemberships(username string) string {
-	//return fmt.Sprintf("/learn/api/public/v1/users/userName:%s/courses", username)
-	return fmt.Sprintf("/learn/api/public/v1/users/userName:%s/courses?expand=course&fields=courseId,courseRoleId,created,course.externalId,course.name", username)
+// Deprecated: use Users.Get("userName:" + username).
+func (userEndpoints) GetByUsername(username string) string {
+	return Users.Get("userName:" + username)
+}
+
+// Deprecated: use Users.Get("userName:" + username).
+func (userEndpoints) GetId(username string) string {
+	return Users.Get("userName:" + username)
 }
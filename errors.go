@@ -0,0 +1,102 @@
+package chawk
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is returned by every service method when Blackboard answers with
+// a non-2xx status. Use errors.As to get at the details, or errors.Is to
+// check it against the package errors like ErrCourseNotFound.
+type APIError struct {
+	StatusCode       int
+	Code             string // Blackboard error code, if the body had one
+	Message          string
+	DeveloperMessage string
+	ExtraInfo        string
+
+	Method    string
+	Path      string
+	RequestID string // X-Blackboard-Request-Id
+
+	// err is the package error this response maps to, if any.
+	err error
+}
+
+// Blackboard's error body. Status and code come back as strings on some
+// endpoints and numbers on others, so they are decoded loosely.
+type apiErrorBody struct {
+	Status           json.RawMessage `json:"status"`
+	Code             json.RawMessage `json:"code"`
+	Message          string          `json:"message"`
+	DeveloperMessage string          `json:"developerMessage"`
+	ExtraInfo        string          `json:"extraInfo"`
+}
+
+// newAPIError builds an APIError from a response and its already read body.
+// sentinel is the package error the response should match with errors.Is,
+// pass nil when there isn't one.
+func newAPIError(resp *http.Response, body []byte, sentinel error) *APIError {
+	e := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Blackboard-Request-Id"),
+		err:        sentinel,
+	}
+
+	if resp.Request != nil {
+		e.Method = resp.Request.Method
+		if resp.Request.URL != nil {
+			e.Path = resp.Request.URL.Path
+		}
+	}
+
+	var parsed apiErrorBody
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		e.Code = strings.Trim(string(parsed.Code), `"`)
+		e.Message = parsed.Message
+		e.DeveloperMessage = parsed.DeveloperMessage
+		e.ExtraInfo = parsed.ExtraInfo
+	} else {
+		// Not JSON (proxy pages, gateway errors, ...). Keep the raw text.
+		e.Message = strings.TrimSpace(string(body))
+	}
+
+	return e
+}
+
+func (e *APIError) Error() string {
+	var sb strings.Builder
+
+	if e.Method != "" || e.Path != "" {
+		fmt.Fprintf(&sb, "%s %s: ", e.Method, e.Path)
+	}
+	fmt.Fprintf(&sb, "unexpected status %d", e.StatusCode)
+
+	if e.err != nil {
+		fmt.Fprintf(&sb, " (%v)", e.err)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&sb, ": %s", e.Message)
+	}
+	if e.Code != "" {
+		fmt.Fprintf(&sb, " [code %s]", e.Code)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&sb, " [request %s]", e.RequestID)
+	}
+
+	return sb.String()
+}
+
+// Unwrap returns the package error this response maps to, if any.
+func (e *APIError) Unwrap() error {
+	return e.err
+}
+
+// Is reports a 403 as ErrInsufficientPrivileges no matter which service
+// returned it. Everything else is matched through Unwrap.
+func (e *APIError) Is(target error) bool {
+	return target == ErrInsufficientPrivileges && e.StatusCode == http.StatusForbidden
+}
@@ -2,7 +2,6 @@ package chawk
 
 import (
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -15,6 +14,8 @@ type GradebookService struct {
 	client *BlackboardClient
 }
 
+var ErrColumnExist = errors.New("gradebook column already exists")
+
 type ColumnScore struct {
 	Possible float64 `json:"possible"`
 }
@@ -45,66 +46,35 @@ type GradebookColumn struct {
 	ShowStatisticsToStudents bool               `json:"showStatisticsToStudents,omitempty"`
 }
 
-func (gs *GradebookService) GetColumn(ctx context.Context, courseID string, columnID string) error {
+func (gs *GradebookService) GetColumn(ctx context.Context, course CourseRef, columnID string) error {
 	// TODO: implement
 	return errors.New("GetColumn not implemented")
 }
 
-func (gs *GradebookService) GetColumns(ctx context.Context, courseID string) ([]GradebookColumn, error) {
-	courseID, err := RequiredString(courseID, "courseID")
+func (gs *GradebookService) GetColumns(ctx context.Context, course CourseRef) ([]GradebookColumn, error) {
+	path, err := coursePath(course)
 	if err != nil {
 		return nil, err
 	}
 
-	url := endpoints.Gradebook.GetColumns(courseID)
-
-	var allColumns []GradebookColumn
-
-	for {
-		resp, err := gs.client.Get(ctx, url)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get gradebook columns: %w", err)
-		}
-
-		body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-		}
+	pager := NewPager[GradebookColumn](gs.client, endpoints.Gradebook.GetColumns(path))
+	pager.NotFound = ErrCourseNotFound
 
-		var result struct {
-			Results []GradebookColumn `json:"results"`
-			Paging  struct {
-				NextPage string `json:"nextPage"`
-			} `json:"paging"`
-		}
-
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
-		}
-
-		allColumns = append(allColumns, result.Results...)
-
-		if result.Paging.NextPage == "" {
-			break
-		}
-		url = result.Paging.NextPage
+	columns, err := collect(pager.All(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gradebook columns: %w", err)
 	}
 
-	return allColumns, nil
+	return columns, nil
 }
 
-func (gs *GradebookService) CreateColumnPro(ctx context.Context, courseID string, column GradebookColumn) error {
-	courseID, err := RequiredString(courseID, "courseID")
+func (gs *GradebookService) CreateColumnPro(ctx context.Context, course CourseRef, column GradebookColumn) error {
+	path, err := coursePath(course)
 	if err != nil {
 		return err
 	}
 
-	url := endpoints.Gradebook.CreateColumn(courseID)
+	url := endpoints.Gradebook.CreateColumn(path)
 
 	resp, err := gs.client.Post(ctx, url, column)
 	if err != nil {
@@ -120,19 +90,17 @@ func (gs *GradebookService) CreateColumnPro(ctx context.Context, courseID string
 	switch resp.StatusCode {
 	case http.StatusCreated:
 		return nil
-	case http.StatusBadRequest:
-		return fmt.Errorf("invalid request data: %s", string(body))
-	case http.StatusForbidden:
-		return ErrInsufficientPrivileges
+	case http.StatusNotFound:
+		return newAPIError(resp, body, ErrCourseNotFound)
 	case http.StatusConflict:
-		return fmt.Errorf("column %q already exists in course %s", column.Name, courseID)
+		return newAPIError(resp, body, ErrColumnExist)
 	default:
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp, body, nil)
 	}
 }
 
-func (gs *GradebookService) CreateColumn(ctx context.Context, courseID, name, description string, score float64) error {
-	courseID, err := RequiredString(courseID, "courseID")
+func (gs *GradebookService) CreateColumn(ctx context.Context, course CourseRef, name, description string, score float64) error {
+	path, err := coursePath(course)
 	if err != nil {
 		return err
 	}
@@ -144,7 +112,7 @@ func (gs *GradebookService) CreateColumn(ctx context.Context, courseID, name, de
 		Availability: ColumnAvailability{Available: "Yes"},
 	}
 
-	url := endpoints.Gradebook.CreateColumn(courseID)
+	url := endpoints.Gradebook.CreateColumn(path)
 
 	resp, err := gs.client.Post(ctx, url, data)
 	if err != nil {
@@ -160,38 +128,36 @@ func (gs *GradebookService) CreateColumn(ctx context.Context, courseID, name, de
 	switch resp.StatusCode {
 	case http.StatusCreated:
 		return nil
-	case http.StatusBadRequest:
-		return fmt.Errorf("invalid request data: %s", string(body))
-	case http.StatusForbidden:
-		return ErrInsufficientPrivileges
+	case http.StatusNotFound:
+		return newAPIError(resp, body, ErrCourseNotFound)
 	case http.StatusConflict:
-		return fmt.Errorf("column %q already exists in course %s", name, courseID)
+		return newAPIError(resp, body, ErrColumnExist)
 	default:
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp, body, nil)
 	}
 }
 
-func (gs *GradebookService) DeleteColumn(ctx context.Context, courseID string, columnID string) error {
+func (gs *GradebookService) DeleteColumn(ctx context.Context, course CourseRef, columnID string) error {
 	// TODO: implement
 	return errors.New("DeleteColumn not implemented")
 }
 
-func (gs *GradebookService) DeleteColumns(ctx context.Context, courseID string) error {
+func (gs *GradebookService) DeleteColumns(ctx context.Context, course CourseRef) error {
 	// TODO: implement
 	return errors.New("DeleteColumns not implemented")
 }
 
-// func (gs *GradebookService) GetColumnValue(ctx context.Context, courseID string, columnID string) error {
+// func (gs *GradebookService) GetColumnValue(ctx context.Context, course CourseRef, columnID string) error {
 // 	// TODO: implement
 // 	return errors.New("GetColumnValue not implemented")
 // }
 
-func (gs *GradebookService) UpdateColumnValue(ctx context.Context, courseID string, columnID string) error {
+func (gs *GradebookService) UpdateColumnValue(ctx context.Context, course CourseRef, columnID string) error {
 	// TODO: implement
 	return errors.New("UpdateColumnValue not implemented")
 }
 
-func (gs *GradebookService) UpdateColumnPro(ctx context.Context, courseID string, columnID string) error {
+func (gs *GradebookService) UpdateColumnPro(ctx context.Context, course CourseRef, columnID string) error {
 	// TODO: implement
 	return errors.New("UpdateColumnPro not implemented")
 }
@@ -0,0 +1,167 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// What Offboard does with a user's course memberships.
+const (
+	MembershipsDisable = "disable" // set each membership to Disabled
+	MembershipsRemove  = "remove"  // take the user out of each course
+)
+
+// OffboardOptions controls Offboard.
+type OffboardOptions struct {
+	// Memberships is MembershipsDisable (the default) or MembershipsRemove.
+	Memberships string
+}
+
+// OffboardRecord is everything Offboard changed, with the values from
+// before, so Restore can put it back. It's plain JSON so it can be kept
+// in an audit log and restored months later.
+type OffboardRecord struct {
+	UserID               string               `json:"userId"` // primary ID
+	UserName             string               `json:"userName"`
+	PreviousAvailability string               `json:"previousAvailability,omitempty"` // empty if the account wasn't changed
+	Memberships          []OffboardMembership `json:"memberships"`
+	MembershipAction     string               `json:"membershipAction"`
+	Started              time.Time            `json:"started"`
+	Finished             time.Time            `json:"finished,omitzero"` // zero if Offboard stopped early
+}
+
+// OffboardMembership is one course membership Offboard changed.
+type OffboardMembership struct {
+	CourseID             string `json:"courseId"` // primary ID
+	CourseName           string `json:"courseName,omitempty"`
+	CourseRoleID         string `json:"courseRoleId"`
+	PreviousAvailability string `json:"previousAvailability"`
+	Removed              bool   `json:"removed"`
+}
+
+// Offboard disables a user's account and disables (or removes) every course
+// membership they have. It stops at the first failure; the record it
+// returns then holds what was changed before that, so it can still be
+// handed to Restore.
+func (us *UserService) Offboard(ctx context.Context, user UserRef, opts OffboardOptions) (*OffboardRecord, error) {
+	action := opts.Memberships
+	switch action {
+	case "":
+		action = MembershipsDisable
+	case MembershipsDisable, MembershipsRemove:
+	default:
+		return nil, fmt.Errorf("invalid membership action %q", action)
+	}
+
+	u, err := us.Get(ctx, user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user %s: %w", user, err)
+	}
+
+	enrollments, err := us.GetCourses(ctx, ByPrimaryID(u.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courses of %s: %w", u.UserName, err)
+	}
+
+	record := &OffboardRecord{
+		UserID:           u.ID,
+		UserName:         u.UserName,
+		Memberships:      []OffboardMembership{},
+		MembershipAction: action,
+		Started:          time.Now().UTC(),
+	}
+	self := ByPrimaryID(u.ID)
+
+	// Memberships go first, so an account is never left disabled with live
+	// enrollments if a call fails half way.
+	for _, e := range enrollments {
+		course := ByPrimaryID(e.CourseID)
+		m := OffboardMembership{
+			CourseID:             e.CourseID,
+			CourseName:           e.Name,
+			CourseRoleID:         e.CourseRoleID,
+			PreviousAvailability: e.Available,
+		}
+		if m.PreviousAvailability == "" {
+			m.PreviousAvailability = AvailabilityYes
+		}
+
+		if action == MembershipsRemove {
+			if err := us.client.Courses.RemoveUser(ctx, course, self); err != nil {
+				return record, fmt.Errorf("failed to remove %s from course %s: %w", u.UserName, e.CourseID, err)
+			}
+			m.Removed = true
+		} else {
+			if e.Available == AvailabilityDisabled {
+				continue
+			}
+			if err := us.client.Courses.UpdateMembershipAvailability(ctx, self, course, AvailabilityDisabled); err != nil {
+				return record, fmt.Errorf("failed to disable %s in course %s: %w", u.UserName, e.CourseID, err)
+			}
+		}
+
+		record.Memberships = append(record.Memberships, m)
+		us.client.logger.InfoContext(ctx, "membership offboarded", "user", u.UserName, "course", e.CourseID, "action", action)
+	}
+
+	if u.Availability.Available != AvailabilityDisabled {
+		if err := us.UpdateUserAvailability(ctx, self, AvailabilityDisabled); err != nil {
+			return record, fmt.Errorf("failed to disable %s: %w", u.UserName, err)
+		}
+		record.PreviousAvailability = u.Availability.Available
+	}
+
+	record.Finished = time.Now().UTC()
+	us.client.logger.InfoContext(ctx, "user offboarded", "user", u.UserName, "memberships", len(record.Memberships))
+
+	return record, nil
+}
+
+// Restore undoes an Offboard: the account gets its old availability back and
+// every membership is re-enabled or re-created with its old role. It keeps
+// going past failures and returns them all together.
+func (us *UserService) Restore(ctx context.Context, record *OffboardRecord) error {
+	if record == nil || record.UserID == "" {
+		return errors.New("offboard record has no user")
+	}
+
+	self := ByPrimaryID(record.UserID)
+	var errs []error
+
+	if record.PreviousAvailability != "" {
+		if err := us.UpdateUserAvailability(ctx, self, record.PreviousAvailability); err != nil {
+			// Nothing else will work on an account that can't be found.
+			if errors.Is(err, ErrUserNotFound) {
+				return fmt.Errorf("failed to restore %s: %w", record.UserName, err)
+			}
+			errs = append(errs, fmt.Errorf("failed to restore availability of %s: %w", record.UserName, err))
+		}
+	}
+
+	for _, m := range record.Memberships {
+		course := ByPrimaryID(m.CourseID)
+
+		var err error
+		if m.Removed {
+			err = us.client.Courses.EnrollUserIntoCourse(ctx, course, self, m.CourseRoleID, m.PreviousAvailability)
+			if errors.Is(err, ErrUserAlreadyEnrolled) {
+				err = us.client.Courses.UpdateMembershipAvailability(ctx, self, course, m.PreviousAvailability)
+			}
+		} else {
+			err = us.client.Courses.UpdateMembershipAvailability(ctx, self, course, m.PreviousAvailability)
+		}
+
+		if err != nil {
+			us.client.logger.WarnContext(ctx, "failed to restore membership", "user", record.UserName, "course", m.CourseID, "error", err)
+			errs = append(errs, fmt.Errorf("failed to restore %s in course %s: %w", record.UserName, m.CourseID, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		us.client.logger.InfoContext(ctx, "user restored", "user", record.UserName, "memberships", len(record.Memberships))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,43 @@
+package chawk
+
+import (
+	"log/slog"
+	"net/http"
+)
+
+const redacted = "REDACTED"
+
+// Headers that carry credentials and must never end up in a log.
+var sensitiveHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"Cookie",
+	"Set-Cookie",
+}
+
+// RedactHeaders returns a copy of h that is safe to log, with credential
+// headers replaced. Use it in your own middlewares.
+func RedactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for _, name := range sensitiveHeaders {
+		if out.Get(name) != "" {
+			out.Set(name, redacted)
+		}
+	}
+	return out
+}
+
+// LogValue keeps the access and refresh tokens out of logs when a Token
+// is passed to slog.
+func (t *Token) LogValue() slog.Value {
+	if t == nil {
+		return slog.StringValue("<nil>")
+	}
+	return slog.GroupValue(
+		slog.String("access_token", redacted),
+		slog.Bool("has_refresh_token", t.RefreshToken != ""),
+		slog.Time("expiry", t.Expiry),
+		slog.String("scope", t.Scope),
+		slog.String("user_id", t.UserID),
+	)
+}
@@ -0,0 +1,226 @@
+package chawk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+// GetMembership fetches user's membership in course, with the user
+// expanded. It returns ErrMembershipNotFound if they aren't enrolled.
+func (cs *CourseService) GetMembership(ctx context.Context, user UserRef, course CourseRef) (*CourseMembership, error) {
+	uPath, err := userPath(user)
+	if err != nil {
+		return nil, err
+	}
+	cPath, err := coursePath(course)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cs.client.Get(ctx, endpoints.Courses.GetMembership(cPath, uPath)+"?expand=user")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get membership of %s in %s: %w", user, course, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var m CourseMembership
+		if err := json.NewDecoder(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE)).Decode(&m); err != nil {
+			return nil, fmt.Errorf("failed to decode membership: %w", err)
+		}
+		return &m, nil
+	case http.StatusNotFound:
+		// Learn doesn't say whether the course, the user or the membership
+		// is missing.
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return nil, newAPIError(resp, body, ErrMembershipNotFound)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return nil, newAPIError(resp, body, nil)
+	}
+}
+
+// MembershipQuery filters CourseService.ListMemberships. Zero values are
+// left out.
+type MembershipQuery struct {
+	Role         string // a course role ID, e.g. RoleStudent
+	Available    string // AvailabilityYes, AvailabilityNo or AvailabilityDisabled
+	DataSourceID string
+
+	// As with UserQuery, After bounds are inclusive and Before bounds
+	// exclusive. The After bounds are sent to Learn and the Before bounds
+	// are checked here, unless only a Before bound is set.
+	CreatedAfter       time.Time
+	CreatedBefore      time.Time
+	ModifiedAfter      time.Time
+	ModifiedBefore     time.Time
+	LastAccessedAfter  time.Time
+	LastAccessedBefore time.Time
+
+	// Members who have never opened the course have no lastAccessed, so by
+	// default no LastAccessed bound matches them. IncludeNeverAccessed makes
+	// LastAccessedBefore match them too; it can't be used with
+	// LastAccessedAfter.
+	IncludeNeverAccessed bool
+
+	// ExpandUser fills in CourseMembership.User.
+	ExpandUser bool
+	// Fields limits the fields returned for each membership.
+	Fields []string
+
+	PageSize int // results per request, 0 for the server default
+	MaxItems int // stop after this many memberships, 0 for all
+}
+
+func (q MembershipQuery) validate() error {
+	switch q.Available {
+	case "", AvailabilityYes, AvailabilityNo, AvailabilityDisabled:
+	default:
+		return fmt.Errorf("invalid availability %q", q.Available)
+	}
+
+	bounds := []struct {
+		name          string
+		after, before time.Time
+	}{
+		{"Created", q.CreatedAfter, q.CreatedBefore},
+		{"Modified", q.ModifiedAfter, q.ModifiedBefore},
+		{"LastAccessed", q.LastAccessedAfter, q.LastAccessedBefore},
+	}
+	if q.IncludeNeverAccessed && (q.LastAccessedBefore.IsZero() || !q.LastAccessedAfter.IsZero()) {
+		return errors.New("IncludeNeverAccessed needs LastAccessedBefore and no LastAccessedAfter")
+	}
+	for _, b := range bounds {
+		if !b.after.IsZero() && !b.before.IsZero() && b.after.After(b.before) {
+			return fmt.Errorf("%sAfter is after %sBefore", b.name, b.name)
+		}
+	}
+	return nil
+}
+
+// path builds the first page's path for course. It also returns the checks
+// that Learn can't do itself.
+func (q MembershipQuery) path(course string) (string, func(CourseMembership) bool) {
+	v := url.Values{}
+
+	text := map[string]string{
+		"role":                   q.Role,
+		"availability.available": q.Available,
+		"dataSourceId":           q.DataSourceID,
+	}
+	for k, val := range text {
+		if val = strings.TrimSpace(val); val != "" {
+			v.Set(k, val)
+		}
+	}
+
+	if q.ExpandUser {
+		v.Set("expand", "user")
+	}
+	if q.PageSize > 0 {
+		v.Set("limit", strconv.Itoa(q.PageSize))
+	}
+
+	created := dateBound{"created", q.CreatedAfter, q.CreatedBefore}
+	modified := dateBound{"modified", q.ModifiedAfter, q.ModifiedBefore}
+	accessed := dateBound{"lastAccessed", q.LastAccessedAfter, q.LastAccessedBefore}
+	checkCreated, checkModified := created.server(v), modified.server(v)
+	checkAccessed := true
+	if !q.IncludeNeverAccessed {
+		checkAccessed = accessed.server(v)
+	}
+	// Otherwise it's all checked here, since Learn drops members without a
+	// lastAccessed from any lastAccessed filter.
+
+	if len(q.Fields) > 0 {
+		fields := slices.Clone(q.Fields)
+		// In a fixed order, so the same query always gives the same path.
+		needed := []struct {
+			field string
+			check bool
+		}{{"created", checkCreated}, {"modified", checkModified}, {"lastAccessed", checkAccessed}}
+		for _, n := range needed {
+			if n.check && !slices.Contains(fields, n.field) {
+				fields = append(fields, n.field)
+			}
+		}
+		v.Set("fields", strings.Join(fields, ","))
+	}
+
+	keep := func(m CourseMembership) bool {
+		if checkCreated && !created.match(m.Created) {
+			return false
+		}
+		if checkModified && !modified.match(m.Modified) {
+			return false
+		}
+		if checkAccessed && !(q.IncludeNeverAccessed && m.LastAccessed == nil) && !accessed.match(m.LastAccessed) {
+			return false
+		}
+		return true
+	}
+
+	path := endpoints.Courses.GetUsers(course)
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	return path, keep
+}
+
+// ListMemberships yields every membership of course matching q, fetching
+// pages as the loop asks for them.
+//
+//	q := chawk.MembershipQuery{Role: chawk.RoleStudent, LastAccessedBefore: cutoff, IncludeNeverAccessed: true}
+//	for m, err := range client.Courses.ListMemberships(ctx, course, q) {
+//		...
+//	}
+func (cs *CourseService) ListMemberships(ctx context.Context, course CourseRef, q MembershipQuery) iter.Seq2[CourseMembership, error] {
+	return func(yield func(CourseMembership, error) bool) {
+		if err := q.validate(); err != nil {
+			yield(CourseMembership{}, err)
+			return
+		}
+		cPath, err := coursePath(course)
+		if err != nil {
+			yield(CourseMembership{}, err)
+			return
+		}
+
+		path, keep := q.path(cPath)
+		pager := NewPager[CourseMembership](cs.client, path)
+		pager.NotFound = ErrCourseNotFound
+
+		matched := 0
+		for m, err := range pager.All(ctx) {
+			if err != nil {
+				yield(CourseMembership{}, fmt.Errorf("failed to list memberships of %s: %w", course, err))
+				return
+			}
+			if !keep(m) {
+				continue
+			}
+
+			if !yield(m, nil) {
+				return
+			}
+
+			matched++
+			if q.MaxItems > 0 && matched >= q.MaxItems {
+				return
+			}
+		}
+	}
+}
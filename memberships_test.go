@@ -0,0 +1,172 @@
+package chawk_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func TestGetMembership(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+	srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+	srv.AddUser(chawk.User{UserName: "jdoe"})
+	srv.AddUser(chawk.User{UserName: "asmith"})
+	mustDo(t, srv.Enroll("BIO-101", "jdoe", ""))
+	seen := time.Now().Add(-time.Hour).UTC().Truncate(time.Millisecond)
+	mustDo(t, srv.Touch("BIO-101", "jdoe", seen))
+
+	m, err := client.Courses.GetMembership(ctx, chawk.ByUserName("jdoe"), chawk.ByCourseID("BIO-101"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.User == nil || m.User.UserName != "jdoe" {
+		t.Errorf("user not expanded: %+v", m.User)
+	}
+	if m.CourseRoleID == nil || *m.CourseRoleID != chawk.RoleStudent {
+		t.Errorf("CourseRoleID = %v", m.CourseRoleID)
+	}
+	if m.Created == nil || m.LastAccessed == nil || !m.LastAccessed.Equal(seen) {
+		t.Errorf("Created %v, LastAccessed %v; want LastAccessed %v", m.Created, m.LastAccessed, seen)
+	}
+
+	_, err = client.Courses.GetMembership(ctx, chawk.ByUserName("asmith"), chawk.ByCourseID("BIO-101"))
+	if !errors.Is(err, chawk.ErrMembershipNotFound) {
+		t.Errorf("err = %v, want ErrMembershipNotFound", err)
+	}
+}
+
+func TestListMemberships(t *testing.T) {
+	srv, client := newTestServer(t)
+	now := time.Now()
+	srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+	for _, u := range []string{"active", "idle", "never", "prof"} {
+		srv.AddUser(chawk.User{UserName: u})
+	}
+	mustDo(t, srv.Enroll("BIO-101", "active", ""))
+	mustDo(t, srv.Enroll("BIO-101", "idle", ""))
+	mustDo(t, srv.Enroll("BIO-101", "never", ""))
+	mustDo(t, srv.Enroll("BIO-101", "prof", chawk.RoleInstructor))
+	mustDo(t, srv.Touch("BIO-101", "active", now))
+	mustDo(t, srv.Touch("BIO-101", "idle", now.AddDate(0, 0, -30)))
+	mustDo(t, srv.Touch("BIO-101", "prof", now.AddDate(0, 0, -1)))
+
+	cutoff := now.AddDate(0, 0, -14)
+	tests := []struct {
+		name    string
+		q       chawk.MembershipQuery
+		want    []string
+		wantErr bool
+	}{
+		{name: "all", want: []string{"active", "idle", "never", "prof"}},
+		{name: "role", q: chawk.MembershipQuery{Role: chawk.RoleInstructor}, want: []string{"prof"}},
+		{name: "accessed before", q: chawk.MembershipQuery{LastAccessedBefore: cutoff}, want: []string{"idle"}},
+		{
+			name: "accessed before or never",
+			q:    chawk.MembershipQuery{Role: chawk.RoleStudent, LastAccessedBefore: cutoff, IncludeNeverAccessed: true},
+			want: []string{"idle", "never"},
+		},
+		{
+			name: "accessed between",
+			q:    chawk.MembershipQuery{LastAccessedAfter: now.AddDate(0, 0, -2), LastAccessedBefore: now.Add(-time.Hour)},
+			want: []string{"prof"},
+		},
+		{name: "paged and capped", q: chawk.MembershipQuery{PageSize: 1, MaxItems: 3}, want: []string{"active", "idle", "never"}},
+		{name: "bad availability", q: chawk.MembershipQuery{Available: "Maybe"}, wantErr: true},
+		{name: "never without before", q: chawk.MembershipQuery{IncludeNeverAccessed: true}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.q.ExpandUser = true
+
+			var got []string
+			var err error
+			for m, e := range client.Courses.ListMemberships(context.Background(), chawk.ByCourseID("BIO-101"), tt.q) {
+				if e != nil {
+					err = e
+					break
+				}
+				got = append(got, m.User.UserName)
+			}
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListMembershipsNoCourse(t *testing.T) {
+	_, client := newTestServer(t)
+	for _, err := range client.Courses.ListMemberships(context.Background(), chawk.ByCourseID("nope"), chawk.MembershipQuery{}) {
+		if !errors.Is(err, chawk.ErrCourseNotFound) {
+			t.Errorf("err = %v, want ErrCourseNotFound", err)
+		}
+	}
+}
+
+func TestMembershipQueryString(t *testing.T) {
+	day := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		q    chawk.MembershipQuery
+		want string
+	}{
+		{
+			name: "before",
+			q:    chawk.MembershipQuery{LastAccessedBefore: day},
+			want: "lastAccessed=2025-07-01T00%3A00%3A00Z&lastAccessedCompare=lessThan",
+		},
+		{
+			name: "after",
+			q:    chawk.MembershipQuery{CreatedAfter: day, Role: chawk.RoleStudent},
+			want: "created=2025-07-01T00%3A00%3A00Z&createdCompare=greaterOrEqual&role=Student",
+		},
+		{
+			name: "never accessed is checked here",
+			q:    chawk.MembershipQuery{LastAccessedBefore: day, IncludeNeverAccessed: true, ExpandUser: true},
+			want: "expand=user",
+		},
+		{
+			name: "fields get the checked dates",
+			q: chawk.MembershipQuery{
+				CreatedAfter: day, CreatedBefore: day.AddDate(0, 1, 0),
+				ModifiedAfter: day, ModifiedBefore: day.AddDate(0, 1, 0),
+				LastAccessedBefore: day, IncludeNeverAccessed: true,
+				Fields: []string{"id"},
+			},
+			want: "created=2025-07-01T00%3A00%3A00Z&createdCompare=greaterOrEqual&fields=id%2Ccreated%2Cmodified%2ClastAccessed" +
+				"&modified=2025-07-01T00%3A00%3A00Z&modifiedCompare=greaterOrEqual",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t)
+			srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+			for _, err := range client.Courses.ListMemberships(context.Background(), chawk.ByCourseID("BIO-101"), tt.q) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := lastQuery(t, srv, "/learn/api/public/v1/courses/courseId:BIO-101/users"); got != tt.want {
+				t.Errorf("query\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
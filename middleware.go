@@ -0,0 +1,87 @@
+package chawk
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// Doer sends a single HTTP request. *http.Client is a Doer.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// DoerFunc lets a plain function be used as a Doer.
+type DoerFunc func(req *http.Request) (*http.Response, error)
+
+func (f DoerFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// Middleware wraps the client's Doer. Middlewares see every HTTP exchange
+// the client makes, including the token request and each retry.
+type Middleware func(next Doer) Doer
+
+// WithMiddleware adds middlewares to the client. The first one passed is
+// the outermost, so it sees the request first and the response last.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *BlackboardClient) error {
+		c.Use(mw...)
+		return nil
+	}
+}
+
+// Use adds middlewares after the ones already set.
+// Call it before the client is shared between goroutines.
+func (c *BlackboardClient) Use(mw ...Middleware) {
+	c.middleware = append(c.middleware, mw...)
+}
+
+// doer returns the http client wrapped in the middleware chain.
+func (c *BlackboardClient) doer() Doer {
+	var d Doer = c.httpClient
+	for i := len(c.middleware) - 1; i >= 0; i-- {
+		d = c.middleware[i](d)
+	}
+	return d
+}
+
+// LoggingMiddleware logs the method, path, status and latency of every
+// request at info level. Request headers are logged at debug level with
+// credentials redacted. Bodies are never logged.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			logger.DebugContext(req.Context(), "blackboard request headers", "method", req.Method, "path", req.URL.Path, "headers", RedactHeaders(req.Header))
+
+			start := time.Now()
+			resp, err := next.Do(req)
+
+			attrs := []any{
+				"method", req.Method,
+				"path", req.URL.Path,
+				"duration", time.Since(start),
+			}
+			if err != nil {
+				logger.ErrorContext(req.Context(), "blackboard request failed", append(attrs, "error", err)...)
+				return resp, err
+			}
+
+			logger.InfoContext(req.Context(), "blackboard request", append(attrs, "status", resp.StatusCode)...)
+			return resp, err
+		})
+	}
+}
+
+// TimingMiddleware calls observe after every request with how long it took,
+// e.g. to feed a metrics histogram. resp is nil when err is set.
+func TimingMiddleware(observe func(req *http.Request, resp *http.Response, took time.Duration, err error)) Middleware {
+	return func(next Doer) Doer {
+		return DoerFunc(func(req *http.Request) (*http.Response, error) {
+			start := time.Now()
+			resp, err := next.Do(req)
+			observe(req, resp, time.Since(start), err)
+			return resp, err
+		})
+	}
+}
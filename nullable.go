@@ -0,0 +1,65 @@
+package chawk
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Nullable is a field of a PATCH body with three states: left out (the zero
+// value), set to a value, or cleared to null. Use it with the omitzero tag.
+//
+//	patch := chawk.UserPatch{
+//		StudentID: chawk.Set("S1234"),
+//		Pronouns:  chawk.Null[string](),
+//	}
+type Nullable[T any] struct {
+	value T
+	set   bool
+	null  bool
+}
+
+// Set returns a Nullable holding v.
+func Set[T any](v T) Nullable[T] {
+	return Nullable[T]{value: v, set: true}
+}
+
+// Null returns a Nullable that clears the field.
+func Null[T any]() Nullable[T] {
+	return Nullable[T]{null: true}
+}
+
+// IsZero reports whether the field is left out. omitzero uses it.
+func (n Nullable[T]) IsZero() bool {
+	return !n.set && !n.null
+}
+
+// IsNull reports whether the field is cleared.
+func (n Nullable[T]) IsNull() bool {
+	return n.null
+}
+
+// Get returns the value and whether one is set.
+func (n Nullable[T]) Get() (T, bool) {
+	return n.value, n.set
+}
+
+func (n Nullable[T]) MarshalJSON() ([]byte, error) {
+	if !n.set {
+		return []byte("null"), nil
+	}
+	return json.Marshal(n.value)
+}
+
+func (n *Nullable[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*n = Null[T]()
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*n = Set(v)
+	return nil
+}
@@ -0,0 +1,144 @@
+package chawk
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+// This file has the three-legged (authorization code) OAuth flow, for tools
+// that need to act as the logged in user instead of the integration user.
+//
+//	pkce, _ := chawk.NewPKCE()
+//	http.Redirect(w, r, client.AuthorizationURL(redirect, state, "read write offline", pkce), http.StatusFound)
+//	// ... in the redirect handler
+//	tok, err := client.ExchangeCode(ctx, r.FormValue("code"), redirect, pkce)
+//	instructor := client.ForUser(tok, nil)
+
+// PKCE holds the code verifier for one authorization request. Keep it
+// (in the user's session) until the code comes back.
+type PKCE struct {
+	Verifier  string
+	Challenge string
+	Method    string
+}
+
+// NewPKCE makes a random S256 code verifier and its challenge.
+func NewPKCE() (*PKCE, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to create code verifier: %w", err)
+	}
+
+	verifier := base64.RawURLEncoding.EncodeToString(buf)
+	sum := sha256.Sum256([]byte(verifier))
+
+	return &PKCE{
+		Verifier:  verifier,
+		Challenge: base64.RawURLEncoding.EncodeToString(sum[:]),
+		Method:    "S256",
+	}, nil
+}
+
+// AuthorizationURL builds the Learn login/consent URL to send the user to.
+// scope is space separated, e.g. "read write offline"; "offline" is what
+// gets a refresh token back. pkce may be nil.
+func (c *BlackboardClient) AuthorizationURL(redirectURI, state, scope string, pkce *PKCE) string {
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {c.clientID},
+		"redirect_uri":  {redirectURI},
+	}
+	if scope != "" {
+		q.Set("scope", scope)
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	if pkce != nil {
+		q.Set("code_challenge", pkce.Challenge)
+		q.Set("code_challenge_method", pkce.Method)
+	}
+
+	return c.BaseURL + endpoints.AuthorizationCode() + "?" + q.Encode()
+}
+
+// ExchangeCode trades the code Learn sent to redirectURI for a user token.
+// redirectURI and pkce must match what was passed to AuthorizationURL.
+func (c *BlackboardClient) ExchangeCode(ctx context.Context, code, redirectURI string, pkce *PKCE) (*Token, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, errors.New("authorization code is required")
+	}
+
+	params := url.Values{
+		"code":         {code},
+		"redirect_uri": {redirectURI},
+	}
+	if pkce != nil {
+		params.Set("code_verifier", pkce.Verifier)
+	}
+
+	return c.fetchToken(ctx, "authorization_code", params)
+}
+
+// RefreshToken gets a new user token with a refresh token. Clients made
+// with ForUser do this on their own when their token runs out.
+func (c *BlackboardClient) RefreshToken(ctx context.Context, refreshToken string) (*Token, error) {
+	refreshToken = strings.TrimSpace(refreshToken)
+	if refreshToken == "" {
+		return nil, ErrRefreshTokenMissing
+	}
+
+	t, err := c.fetchToken(ctx, "refresh_token", url.Values{"refresh_token": {refreshToken}})
+	if err != nil {
+		return nil, err
+	}
+	if t.RefreshToken == "" {
+		t.RefreshToken = refreshToken
+	}
+	return t, nil
+}
+
+// ForUser returns a client that makes every call as the user token belongs
+// to. It shares the app's HTTP client, middleware, retry policy and logger,
+// so calls from all users count against, and are throttled with, the same
+// app key.
+// The user's token is cached in store, or in memory when store is nil.
+func (c *BlackboardClient) ForUser(token *Token, store TokenStore) *BlackboardClient {
+	if store == nil {
+		store = NewMemoryTokenStore()
+	}
+
+	uc := &BlackboardClient{
+		clientID:     c.clientID,
+		clientSecret: c.clientSecret,
+		BaseURL:      c.BaseURL,
+		token:        token,
+		tokenStore:   store,
+		httpClient:   c.httpClient,
+		logger:       c.logger,
+		rateLimit:    c.rateLimit,
+		middleware:   slices.Clone(c.middleware),
+		delegated:    true,
+		UserAgent:    c.UserAgent,
+		Retry:        c.Retry,
+	}
+	uc.initServices()
+
+	if token != nil {
+		if err := store.Save(context.Background(), token); err != nil {
+			c.logger.Warn("failed to save user token", "error", err)
+		}
+	}
+
+	return uc
+}
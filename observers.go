@@ -0,0 +1,233 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+var ErrAlreadyObserving = errors.New("user is already an observer of that user")
+
+// Observers (usually parents) can follow another user, the observee, in the
+// courses that allow it. Learn keeps the link on the observee.
+
+type observerResult struct {
+	ObserverID string `json:"observerId"`
+}
+
+type observeeResult struct {
+	ObserveeID string `json:"observeeId"`
+}
+
+// ListObservers returns the primary IDs of everyone observing user.
+func (us *UserService) ListObservers(ctx context.Context, user UserRef) ([]string, error) {
+	path, err := userPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	pager := NewPager[observerResult](us.client, endpoints.Users.Observers(path))
+	pager.NotFound = ErrUserNotFound
+
+	var ids []string
+	for r, err := range pager.All(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get observers of %s: %w", user, err)
+		}
+		ids = append(ids, r.ObserverID)
+	}
+	return ids, nil
+}
+
+// ListObservees returns the primary IDs of everyone observer is observing.
+func (us *UserService) ListObservees(ctx context.Context, observer UserRef) ([]string, error) {
+	path, err := userPath(observer)
+	if err != nil {
+		return nil, err
+	}
+
+	pager := NewPager[observeeResult](us.client, endpoints.Users.Observees(path))
+	pager.NotFound = ErrUserNotFound
+
+	var ids []string
+	for r, err := range pager.All(ctx) {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get observees of %s: %w", observer, err)
+		}
+		ids = append(ids, r.ObserveeID)
+	}
+	return ids, nil
+}
+
+// AddObserver makes observer an observer of user. It returns
+// ErrAlreadyObserving if they already are.
+func (us *UserService) AddObserver(ctx context.Context, user, observer UserRef) error {
+	uPath, err := userPath(user)
+	if err != nil {
+		return err
+	}
+	oPath, err := userPath(observer)
+	if err != nil {
+		return err
+	}
+
+	resp, err := us.client.Put(ctx, endpoints.Users.Observer(uPath, oPath), struct{}{})
+	if err != nil {
+		return fmt.Errorf("failed to add observer %s to %s: %w", observer, user, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK, http.StatusNoContent:
+		us.client.logger.InfoContext(ctx, "observer added", "user", user, "observer", observer)
+		return nil
+	case http.StatusNotFound:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, ErrUserNotFound)
+	case http.StatusConflict:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, ErrAlreadyObserving)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, nil)
+	}
+}
+
+// RemoveObserver stops observer from observing user.
+func (us *UserService) RemoveObserver(ctx context.Context, user, observer UserRef) error {
+	uPath, err := userPath(user)
+	if err != nil {
+		return err
+	}
+	oPath, err := userPath(observer)
+	if err != nil {
+		return err
+	}
+
+	resp, err := us.client.Delete(ctx, endpoints.Users.Observer(uPath, oPath))
+	if err != nil {
+		return fmt.Errorf("failed to remove observer %s from %s: %w", observer, user, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusOK:
+		us.client.logger.InfoContext(ctx, "observer removed", "user", user, "observer", observer)
+		return nil
+	case http.StatusNotFound:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, ErrUserNotFound)
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, nil)
+	}
+}
+
+// ObservedCourse is a course an observer can see through one of their
+// observees.
+type ObservedCourse struct {
+	CourseEnrollment        // the observee's enrollment
+	ObserveeID       string // primary ID
+}
+
+// ObserverCourses returns the courses observer can see: every course one of
+// their observees is enrolled in that allows observers. A course shared by
+// two observees is listed once for each.
+func (us *UserService) ObserverCourses(ctx context.Context, observer UserRef) ([]ObservedCourse, error) {
+	observees, err := us.ListObservees(ctx, observer)
+	if err != nil {
+		return nil, err
+	}
+
+	var courses []ObservedCourse
+	for _, id := range observees {
+		enrollments, err := us.GetCourses(ctx, ByPrimaryID(id))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get courses of observee %s: %w", id, err)
+		}
+		for _, e := range enrollments {
+			if e.AllowObservers {
+				courses = append(courses, ObservedCourse{CourseEnrollment: e, ObserveeID: id})
+			}
+		}
+	}
+	return courses, nil
+}
+
+// ObserverLinkOptions controls LinkObservers.
+type ObserverLinkOptions struct {
+	// ObserverColumn and ObserveeColumn name the CSV columns holding the
+	// userNames. They default to "observer" and "observee".
+	ObserverColumn string
+	ObserveeColumn string
+
+	// Concurrency is how many links are made at once, see
+	// DefaultConcurrency.
+	Concurrency int
+}
+
+// ObserverLinkResult is the outcome of one CSV row.
+type ObserverLinkResult struct {
+	Line     int // line in the CSV file
+	Observer string
+	Observee string
+	Existed  bool  // the link was already there
+	Err      error // nil if the link is in place
+}
+
+// LinkObservers reads a CSV of observer and observee userNames, one pair per
+// row, and links each pair. Pairs that are already linked count as done.
+//
+// The returned error is only for problems with the file itself; a row that
+// fails is reported in its result and the rest carry on.
+func (us *UserService) LinkObservers(ctx context.Context, r io.Reader, opts ObserverLinkOptions) ([]ObserverLinkResult, error) {
+	observerCol, observeeCol := opts.ObserverColumn, opts.ObserveeColumn
+	if observerCol == "" {
+		observerCol = "observer"
+	}
+	if observeeCol == "" {
+		observeeCol = "observee"
+	}
+
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range []string{observerCol, observeeCol} {
+		if !table.has(col) {
+			return nil, fmt.Errorf("csv has no column %q", col)
+		}
+	}
+
+	results := make([]ObserverLinkResult, len(table.rows))
+	for i, row := range table.rows {
+		results[i] = ObserverLinkResult{
+			Line:     row.line,
+			Observer: table.get(row, observerCol),
+			Observee: table.get(row, observeeCol),
+		}
+	}
+
+	forEach(ctx, len(results), opts.Concurrency, func(i int, err error) {
+		res := &results[i]
+		if err != nil {
+			res.Err = err
+			return
+		}
+		if res.Observer == "" || res.Observee == "" {
+			res.Err = ErrInvalidUsername
+			return
+		}
+
+		res.Err = us.AddObserver(ctx, ByUserName(res.Observee), ByUserName(res.Observer))
+		if errors.Is(res.Err, ErrAlreadyObserving) {
+			res.Existed, res.Err = true, nil
+		}
+	})
+
+	return results, nil
+}
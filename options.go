@@ -0,0 +1,155 @@
+package chawk
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var ErrInvalidBaseURL = errors.New("baseURL must be an absolute http(s) URL")
+
+// Option configures a BlackboardClient in NewClient. Options are applied in
+// order, so WithHTTPClient should come before WithProxy/WithTLSConfig/WithTimeout.
+type Option func(*BlackboardClient) error
+
+// WithHTTPClient uses a copy of hc for every call, including the token request.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *BlackboardClient) error {
+		if hc == nil {
+			return errors.New("http client is nil")
+		}
+		cp := *hc
+		c.httpClient = &cp
+		return nil
+	}
+}
+
+// WithTransport sets the RoundTripper used by the client's http.Client.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *BlackboardClient) error {
+		c.httpClient.Transport = rt
+		return nil
+	}
+}
+
+// WithProxy sends every call through the proxy at proxyURL.
+func WithProxy(proxyURL string) Option {
+	return func(c *BlackboardClient) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("invalid proxy URL: %w", err)
+		}
+
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		t.Proxy = http.ProxyURL(u)
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS config, e.g. for a private CA on a test instance.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(c *BlackboardClient) error {
+		t, err := c.transport()
+		if err != nil {
+			return err
+		}
+		t.TLSClientConfig = cfg
+		return nil
+	}
+}
+
+// WithTimeout replaces the default HTTP_TIMEOUT_SECS timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(c *BlackboardClient) error {
+		c.httpClient.Timeout = d
+		return nil
+	}
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *BlackboardClient) error {
+		c.UserAgent = ua
+		return nil
+	}
+}
+
+// WithLogger sets where the client logs token refreshes, requests, retries
+// and paging. Without it (or with nil) the client logs nothing. Requests are
+// logged at debug level, retries at warn.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *BlackboardClient) error {
+		if logger == nil {
+			logger = slog.New(slog.DiscardHandler)
+		}
+		c.logger = logger
+		return nil
+	}
+}
+
+func WithTokenStore(store TokenStore) Option {
+	return func(c *BlackboardClient) error {
+		if store == nil {
+			return errors.New("token store is nil")
+		}
+		c.tokenStore = store
+		return nil
+	}
+}
+
+// WithTokenPath caches the token in a plain JSON file at path.
+func WithTokenPath(path string) Option {
+	return WithTokenStore(NewFileTokenStore(path))
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *BlackboardClient) error {
+		c.Retry = policy
+		return nil
+	}
+}
+
+// transport returns a copy of the client's *http.Transport, already in use
+// by the client, so options can tweak it. It's always a copy since the
+// transport passed in (or http.DefaultTransport) may be shared.
+func (c *BlackboardClient) transport() (*http.Transport, error) {
+	switch t := c.httpClient.Transport.(type) {
+	case nil:
+		cloned := http.DefaultTransport.(*http.Transport).Clone()
+		c.httpClient.Transport = cloned
+		return cloned, nil
+	case *http.Transport:
+		cloned := t.Clone()
+		c.httpClient.Transport = cloned
+		return cloned, nil
+	default:
+		return nil, fmt.Errorf("cannot configure custom transport %T, set it up before passing it in", t)
+	}
+}
+
+// normalizeBaseURL checks that baseURL is an absolute http(s) URL and drops
+// any trailing slashes, since every endpoint path starts with one.
+func normalizeBaseURL(baseURL string) (string, error) {
+	baseURL = strings.TrimSpace(baseURL)
+
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidBaseURL, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidBaseURL, baseURL)
+	}
+	if u.RawQuery != "" || u.Fragment != "" {
+		return "", fmt.Errorf("%w: %q has a query or fragment", ErrInvalidBaseURL, baseURL)
+	}
+
+	return strings.TrimRight(u.String(), "/"), nil
+}
@@ -0,0 +1,47 @@
+package chawk
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+)
+
+func TestTransportOptionsDontChangeCallersTransport(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  func(*http.Transport) Option
+	}{
+		{name: "WithTransport", opt: func(tr *http.Transport) Option { return WithTransport(tr) }},
+		{name: "WithHTTPClient", opt: func(tr *http.Transport) Option { return WithHTTPClient(&http.Client{Transport: tr}) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shared := &http.Transport{}
+			cfg := &tls.Config{ServerName: "learn.example.edu"}
+
+			c, err := NewClient("id", "secret", "https://learn.example.edu",
+				WithTokenStore(NewMemoryTokenStore()),
+				tt.opt(shared),
+				WithProxy("http://proxy.example.edu:3128"),
+				WithTLSConfig(cfg),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Clone fills in shared's HTTP/2 defaults, so only look for ours.
+			if shared.Proxy != nil || shared.TLSClientConfig == cfg {
+				t.Error("caller's transport was changed")
+			}
+
+			got, ok := c.httpClient.Transport.(*http.Transport)
+			if !ok || got == shared {
+				t.Fatalf("client uses %T %p, want a copy of %p", c.httpClient.Transport, c.httpClient.Transport, shared)
+			}
+			if got.Proxy == nil || got.TLSClientConfig != cfg {
+				t.Error("proxy and TLS config didn't both reach the client's transport")
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package chawk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+)
+
+// Page is one page of a Blackboard list response.
+type Page[T any] struct {
+	Results []T `json:"results"`
+	Paging  struct {
+		NextPage string `json:"nextPage"`
+	} `json:"paging"`
+}
+
+// Pager walks a Blackboard list endpoint one page at a time, following
+// paging.nextPage until the server stops sending one.
+type Pager[T any] struct {
+	client *BlackboardClient
+	next   string
+	seen   int
+
+	// MaxItems stops the pager after this many results. 0 means no limit.
+	MaxItems int
+
+	// NotFound is the error a 404 maps to, e.g. ErrCourseNotFound.
+	NotFound error
+}
+
+// NewPager returns a Pager that starts at path.
+func NewPager[T any](c *BlackboardClient, path string) *Pager[T] {
+	return &Pager[T]{client: c, next: path}
+}
+
+// ResumePager returns a Pager that picks up from a cursor saved with
+// Pager.Cursor.
+func ResumePager[T any](c *BlackboardClient, cursor string) *Pager[T] {
+	return NewPager[T](c, cursor)
+}
+
+// Cursor is the path of the next page to fetch, or "" when done.
+// Save it to resume a long walk later with ResumePager.
+func (p *Pager[T]) Cursor() string {
+	return p.next
+}
+
+// Done reports whether there is nothing left to fetch.
+func (p *Pager[T]) Done() bool {
+	return p.next == "" || (p.MaxItems > 0 && p.seen >= p.MaxItems)
+}
+
+// NextPage fetches the next page. It returns nil, nil once the pager is done.
+func (p *Pager[T]) NextPage(ctx context.Context) ([]T, error) {
+	if p.Done() {
+		return nil, nil
+	}
+
+	resp, err := p.client.Get(ctx, p.next)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get page: %w", err)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, newAPIError(resp, body, p.NotFound)
+	default:
+		return nil, newAPIError(resp, body, nil)
+	}
+
+	var page Page[T]
+	if err := json.Unmarshal(body, &page); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	results := page.Results
+	if p.MaxItems > 0 && p.seen+len(results) > p.MaxItems {
+		results = results[:p.MaxItems-p.seen]
+	}
+	p.seen += len(results)
+	p.next = page.Paging.NextPage
+
+	p.client.logger.DebugContext(ctx, "fetched page", "results", len(results), "total", p.seen, "more", p.next != "")
+
+	return results, nil
+}
+
+// All yields every remaining result, fetching pages as they are needed.
+// Only one page is held in memory at a time.
+func (p *Pager[T]) All(ctx context.Context) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for !p.Done() {
+			results, err := p.NextPage(ctx)
+			if err != nil {
+				var zero T
+				yield(zero, err)
+				return
+			}
+
+			for _, r := range results {
+				if !yield(r, nil) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Paginate yields every result of a list endpoint, starting at path.
+func Paginate[T any](ctx context.Context, c *BlackboardClient, path string) iter.Seq2[T, error] {
+	return NewPager[T](c, path).All(ctx)
+}
+
+// collect drains seq into a slice, stopping at the first error.
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var all []T
+	for v, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, v)
+	}
+	return all, nil
+}
@@ -0,0 +1,151 @@
+package chawk_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/sugarvoid/chawk"
+)
+
+const usersPath = "/learn/api/public/v1/users?fields=userName"
+
+func userNames(users []chawk.User) []string {
+	var names []string
+	for _, u := range users {
+		names = append(names, u.UserName)
+	}
+	return names
+}
+
+func TestPager(t *testing.T) {
+	all := []string{"u1", "u2", "u3", "u4", "u5"}
+
+	tests := []struct {
+		name      string
+		pageSize  int
+		maxItems  int
+		want      []string
+		wantPages int // requests made to the list endpoint
+	}{
+		{name: "one page", pageSize: 100, want: all, wantPages: 1},
+		{name: "exact pages", pageSize: 5, want: all, wantPages: 1},
+		{name: "several pages", pageSize: 2, want: all, wantPages: 3},
+		{name: "page of one", pageSize: 1, want: all, wantPages: 5},
+		{name: "capped mid page", pageSize: 2, maxItems: 3, want: all[:3], wantPages: 2},
+		{name: "capped on page end", pageSize: 2, maxItems: 4, want: all[:4], wantPages: 2},
+		{name: "cap above total", pageSize: 2, maxItems: 50, want: all, wantPages: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t)
+			srv.PageSize = tt.pageSize
+			for _, name := range all {
+				srv.AddUser(chawk.User{UserName: name})
+			}
+
+			p := chawk.NewPager[chawk.User](client, usersPath)
+			p.MaxItems = tt.maxItems
+
+			var got []chawk.User
+			for u, err := range p.All(context.Background()) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, u)
+			}
+
+			if names := userNames(got); !slices.Equal(names, tt.want) {
+				t.Errorf("got %v, want %v", names, tt.want)
+			}
+			if !p.Done() {
+				t.Error("pager not done after All")
+			}
+
+			pages := 0
+			for _, r := range srv.Requests() {
+				if r.Path == "/learn/api/public/v1/users" {
+					pages++
+				}
+			}
+			if pages != tt.wantPages {
+				t.Errorf("fetched %d pages, want %d", pages, tt.wantPages)
+			}
+		})
+	}
+}
+
+func TestResumePager(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+	srv.PageSize = 2
+	for _, name := range []string{"u1", "u2", "u3", "u4", "u5"} {
+		srv.AddUser(chawk.User{UserName: name})
+	}
+
+	first := chawk.NewPager[chawk.User](client, usersPath)
+	page, err := first.NextPage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursor := first.Cursor()
+	if cursor == "" || first.Done() {
+		t.Fatalf("pager done after one page of %d", len(page))
+	}
+
+	// A new pager, as if the job had restarted, carries on from the cursor.
+	resumed := chawk.ResumePager[chawk.User](client, cursor)
+	for u, err := range resumed.All(ctx) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		page = append(page, u)
+	}
+
+	if got, want := userNames(page), []string{"u1", "u2", "u3", "u4", "u5"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if resumed.Cursor() != "" {
+		t.Errorf("Cursor = %q after the last page, want empty", resumed.Cursor())
+	}
+
+	page, err = resumed.NextPage(ctx)
+	if page != nil || err != nil {
+		t.Errorf("NextPage after done = %v, %v; want nil, nil", page, err)
+	}
+}
+
+func TestPagerErrors(t *testing.T) {
+	_, client := newTestServer(t)
+	ctx := context.Background()
+
+	p := chawk.NewPager[chawk.CourseMembership](client, "/learn/api/public/v1/courses/courseId:NOPE/users")
+	p.NotFound = chawk.ErrCourseNotFound
+	_, err := p.NextPage(ctx)
+	if !errors.Is(err, chawk.ErrCourseNotFound) {
+		t.Errorf("err = %v, want ErrCourseNotFound", err)
+	}
+	var apiErr *chawk.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != 404 {
+		t.Errorf("err = %v, want an *APIError with status 404", err)
+	}
+
+	// Without NotFound the 404 is still an *APIError, just not a sentinel.
+	_, err = collect(chawk.Paginate[chawk.CourseMembership](ctx, client, "/learn/api/public/v1/courses/courseId:NOPE/users"))
+	if !errors.As(err, &apiErr) || errors.Is(err, chawk.ErrCourseNotFound) {
+		t.Errorf("err = %v, want a plain *APIError", err)
+	}
+}
+
+func collect[T any](seq func(func(T, error) bool)) ([]T, error) {
+	var all []T
+	for v, err := range seq {
+		if err != nil {
+			return all, err
+		}
+		all = append(all, v)
+	}
+	return all, nil
+}
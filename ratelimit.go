@@ -0,0 +1,151 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var ErrRateLimitReserve = errors.New("api quota is down to the reserved calls")
+
+// RateLimitStatus is the app key's quota as of the last response that
+// carried the X-Rate-Limit-* headers.
+type RateLimitStatus struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time // when the quota refills
+	Updated   time.Time // zero until a response had the headers
+}
+
+// Known reports whether any response has reported the quota yet.
+func (s RateLimitStatus) Known() bool {
+	return !s.Updated.IsZero()
+}
+
+// ThrottlePolicy slows the client down as the daily quota runs out, so one
+// bulk job can't use up the whole institution's calls.
+type ThrottlePolicy struct {
+	// SlowdownBelow is the fraction of the limit (0-1) below which calls get
+	// spread out evenly over the time left until the reset.
+	SlowdownBelow float64
+
+	// Reserve calls are never used. Once Remaining gets down to it, calls
+	// fail with ErrRateLimitReserve until the quota resets. Token requests
+	// don't spend the quota and are never held back.
+	Reserve int
+
+	// MaxDelay caps the wait between two calls. 0 means one minute.
+	MaxDelay time.Duration
+}
+
+// rateLimitTracker is shared by a client and every client made from it
+// with ForUser, since they all spend the same app key.
+type rateLimitTracker struct {
+	mu       sync.Mutex
+	status   RateLimitStatus
+	throttle *ThrottlePolicy
+	next     time.Time // earliest time the next throttled call may go out
+}
+
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{}
+}
+
+func (t *rateLimitTracker) get() RateLimitStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.status
+}
+
+func (t *rateLimitTracker) setThrottle(p *ThrottlePolicy) {
+	t.mu.Lock()
+	t.throttle = p
+	t.mu.Unlock()
+}
+
+// record reads the quota headers off a response. Responses without them
+// (token calls, some errors) are ignored.
+func (t *rateLimitTracker) record(h http.Header) {
+	remaining, err := strconv.Atoi(h.Get("X-Rate-Limit-Remaining"))
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.status.Remaining = remaining
+	t.status.Updated = now
+
+	if limit, err := strconv.Atoi(h.Get("X-Rate-Limit-Limit")); err == nil {
+		t.status.Limit = limit
+	}
+	if secs, err := strconv.Atoi(h.Get("X-Rate-Limit-Reset")); err == nil {
+		t.status.Reset = now.Add(time.Duration(secs) * time.Second)
+	}
+}
+
+// wait blocks until the throttle lets the next call out, or fails once the
+// quota is down to the reserve.
+func (t *rateLimitTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+
+	p := t.throttle
+	s := t.status
+	now := time.Now()
+
+	// Nothing to go on, or the quota has refilled since we last heard.
+	if p == nil || !s.Known() || (!s.Reset.IsZero() && now.After(s.Reset)) {
+		t.mu.Unlock()
+		return nil
+	}
+
+	if s.Remaining <= p.Reserve {
+		t.mu.Unlock()
+		return ErrRateLimitReserve
+	}
+
+	// Count the call now, so concurrent callers see it before the
+	// response comes back with the real number, and pace on what's left.
+	t.status.Remaining--
+	remaining := t.status.Remaining
+
+	if s.Limit <= 0 || float64(remaining)/float64(s.Limit) >= p.SlowdownBelow || s.Reset.IsZero() {
+		t.mu.Unlock()
+		return nil
+	}
+
+	maxDelay := p.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = time.Minute
+	}
+
+	spacing := min(s.Reset.Sub(now)/time.Duration(max(remaining-p.Reserve, 1)), maxDelay)
+
+	at := now
+	if t.next.After(now) {
+		at = t.next
+	}
+	t.next = at.Add(spacing)
+	t.mu.Unlock()
+
+	return sleepCtx(ctx, at.Sub(now))
+}
+
+// RateLimit returns the quota as of the last response. It doesn't make a call.
+func (c *BlackboardClient) RateLimit() RateLimitStatus {
+	return c.rateLimit.get()
+}
+
+// WithThrottle turns on client side throttling, see ThrottlePolicy.
+func WithThrottle(policy ThrottlePolicy) Option {
+	return func(c *BlackboardClient) error {
+		c.rateLimit.setThrottle(&policy)
+		return nil
+	}
+}
@@ -0,0 +1,97 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestThrottleSpacing(t *testing.T) {
+	tests := []struct {
+		name      string
+		remaining int
+		reserve   int
+		want      time.Duration // gap left before the next call
+	}{
+		// 10s split over the 4 calls left after this one.
+		{name: "counts this call", remaining: 5, want: 2500 * time.Millisecond},
+		{name: "minus the reserve", remaining: 5, reserve: 2, want: 5 * time.Second},
+		{name: "last call before the reserve", remaining: 3, reserve: 2, want: 8 * time.Second},
+		{name: "above SlowdownBelow", remaining: 60},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := newRateLimitTracker()
+			tr.setThrottle(&ThrottlePolicy{SlowdownBelow: 0.5, Reserve: tt.reserve, MaxDelay: 8 * time.Second})
+			now := time.Now()
+			tr.status = RateLimitStatus{Limit: 100, Remaining: tt.remaining, Reset: now.Add(10 * time.Second), Updated: now}
+
+			if err := tr.wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if tr.status.Remaining != tt.remaining-1 {
+				t.Errorf("Remaining = %d, want %d", tr.status.Remaining, tt.remaining-1)
+			}
+
+			var gap time.Duration
+			if !tr.next.IsZero() {
+				gap = tr.next.Sub(now)
+			}
+			if gap < tt.want-100*time.Millisecond || gap > tt.want+100*time.Millisecond {
+				t.Errorf("next call in %v, want about %v", gap, tt.want)
+			}
+		})
+	}
+}
+
+// A client held at the reserve still has to be able to get a token.
+func TestThrottleSkipsTokenRequests(t *testing.T) {
+	var tokens, calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/learn/api/public/v1/oauth2/token" {
+			tokens.Add(1)
+			fmt.Fprintf(w, `{"access_token":"tok%d","token_type":"bearer","expires_in":3600}`, tokens.Load())
+			return
+		}
+		calls.Add(1)
+		w.Header().Set("X-Rate-Limit-Limit", "100")
+		w.Header().Set("X-Rate-Limit-Remaining", "5")
+		w.Header().Set("X-Rate-Limit-Reset", "3600")
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("key", "secret", srv.URL,
+		WithTokenStore(NewMemoryTokenStore()),
+		WithThrottle(ThrottlePolicy{SlowdownBelow: 0.5, Reserve: 5}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	resp, err := client.Get(ctx, "/learn/api/public/v1/users?limit=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	// Drop the token, as if it had expired.
+	client.SetTokenStore(NewMemoryTokenStore())
+
+	_, err = client.Get(ctx, "/learn/api/public/v1/users?limit=1")
+	if !errors.Is(err, ErrRateLimitReserve) {
+		t.Errorf("err = %v, want ErrRateLimitReserve", err)
+	}
+	if tokens.Load() != 2 {
+		t.Errorf("%d token requests, want 2", tokens.Load())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("%d API calls, want 1", calls.Load())
+	}
+}
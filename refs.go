@@ -0,0 +1,109 @@
+package chawk
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// CourseRef is any of the IDs Learn takes for a course in a URL path.
+// Make one with ByCourseID, ByExternalID, ByUUID or ByPrimaryID.
+type CourseRef interface {
+	// PathSegment is the ref as it goes in a path, e.g. "courseId:BIO-101".
+	// The ID is escaped, so spaces and slashes are safe.
+	PathSegment() string
+	String() string
+	courseRef()
+}
+
+// UserRef is any of the IDs Learn takes for a user in a URL path.
+// Make one with ByUserName, ByExternalID, ByUUID or ByPrimaryID.
+type UserRef interface {
+	PathSegment() string
+	String() string
+	userRef()
+}
+
+// Ref is an ID that works for courses and users alike.
+type Ref struct {
+	kind  string // "" for the primary ID
+	value string
+}
+
+// ByPrimaryID refers to a course or user by the ID Learn made for it,
+// like "_123_1".
+func ByPrimaryID(id string) Ref {
+	return Ref{value: strings.TrimSpace(id)}
+}
+
+// ByExternalID refers to a course or user by its externalId, which is
+// usually the SIS key.
+func ByExternalID(id string) Ref {
+	return Ref{kind: "externalId", value: strings.TrimSpace(id)}
+}
+
+// ByUUID refers to a course or user by its uuid.
+func ByUUID(uuid string) Ref {
+	return Ref{kind: "uuid", value: strings.TrimSpace(uuid)}
+}
+
+// ByCourseID refers to a course by its courseId, the ID shown in the UI.
+func ByCourseID(courseID string) CourseRef {
+	return courseIDRef{Ref{kind: "courseId", value: strings.TrimSpace(courseID)}}
+}
+
+// ByUserName refers to a user by their userName.
+func ByUserName(userName string) UserRef {
+	return userNameRef{Ref{kind: "userName", value: strings.TrimSpace(userName)}}
+}
+
+func (r Ref) PathSegment() string {
+	if r.value == "" {
+		return ""
+	}
+	if r.kind == "" {
+		return url.PathEscape(r.value)
+	}
+	return r.kind + ":" + url.PathEscape(r.value)
+}
+
+func (r Ref) String() string {
+	if r.kind == "" {
+		return r.value
+	}
+	return r.kind + ":" + r.value
+}
+
+func (Ref) courseRef() {}
+func (Ref) userRef()   {}
+
+// courseIDRef and userNameRef wrap Ref without embedding it, so a courseId
+// can't be passed where a user is expected and the other way around.
+type courseIDRef struct{ ref Ref }
+
+func (r courseIDRef) PathSegment() string { return r.ref.PathSegment() }
+func (r courseIDRef) String() string      { return r.ref.String() }
+func (courseIDRef) courseRef()            {}
+
+type userNameRef struct{ ref Ref }
+
+func (r userNameRef) PathSegment() string { return r.ref.PathSegment() }
+func (r userNameRef) String() string      { return r.ref.String() }
+func (userNameRef) userRef()              {}
+
+// coursePath returns the path segment for course, or an error when it is
+// missing or empty.
+func coursePath(course CourseRef) (string, error) {
+	if course == nil || course.PathSegment() == "" {
+		return "", fmt.Errorf("course is required: %w", ErrEmptyStringParameter)
+	}
+	return course.PathSegment(), nil
+}
+
+// userPath is coursePath for users.
+func userPath(user UserRef) (string, error) {
+	if user == nil || user.PathSegment() == "" {
+		return "", fmt.Errorf("user is required: %w", ErrEmptyStringParameter)
+	}
+	return user.PathSegment(), nil
+}
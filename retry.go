@@ -0,0 +1,187 @@
+package chawk
+
+import (
+	"context"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy controls how sendRequest retries failed calls.
+// A MaxAttempts of 0 or 1 turns retries off.
+type RetryPolicy struct {
+	MaxAttempts int           // total tries, including the first one
+	BaseDelay   time.Duration // delay before the first retry, doubled after each try
+	MaxDelay    time.Duration // upper bound for any single wait, including Retry-After
+
+	// Jitter is the fraction (0-1) of each backoff delay that is randomised,
+	// so a pool of workers doesn't retry in lockstep.
+	Jitter float64
+
+	RetryableStatuses []int
+	RetryableMethods  []string // add http.MethodPost here to opt POSTs in
+}
+
+// DefaultRetryPolicy retries idempotent calls up to 4 times on rate limiting,
+// gateway errors and network failures. POSTs are not retried.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: 4,
+		BaseDelay:   500 * time.Millisecond,
+		MaxDelay:    30 * time.Second,
+		Jitter:      0.2,
+		RetryableStatuses: []int{
+			http.StatusTooManyRequests,
+			http.StatusBadGateway,
+			http.StatusServiceUnavailable,
+			http.StatusGatewayTimeout,
+		},
+		RetryableMethods: []string{
+			http.MethodGet,
+			http.MethodHead,
+			http.MethodPut,
+			http.MethodDelete,
+		},
+	}
+}
+
+func (p RetryPolicy) allowsMethod(method string) bool {
+	return slices.Contains(p.RetryableMethods, method)
+}
+
+func (p RetryPolicy) allowsStatus(status int) bool {
+	return slices.Contains(p.RetryableStatuses, status)
+}
+
+// backoff returns the wait before retry number attempt (starting at 1).
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay
+	for i := 1; i < attempt && d < p.MaxDelay; i++ {
+		d *= 2
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+
+	if p.Jitter > 0 && d > 0 {
+		spread := float64(d) * min(p.Jitter, 1)
+		d = time.Duration(float64(d) - spread + rand.Float64()*2*spread)
+	}
+	return d
+}
+
+// serverDelay reads how long the server asked us to wait, from Retry-After
+// (seconds or an HTTP date) or, for 429s, the rate limit reset header.
+func serverDelay(resp *http.Response) (time.Duration, bool) {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return max(time.Until(t), 0), true
+		}
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		if v := resp.Header.Get("X-Rate-Limit-Reset"); v != "" {
+			if secs, err := strconv.Atoi(v); err == nil {
+				return time.Duration(secs) * time.Second, true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// doWithRetry sends req, retrying according to c.Retry when retryable is set,
+// and waits on the throttle first when throttled is set.
+// Request bodies are replayed through req.GetBody, so a body that can't be
+// rewound is only ever sent once.
+func (c *BlackboardClient) doWithRetry(req *http.Request, retryable, throttled bool) (*http.Response, error) {
+	policy := c.Retry
+	if !retryable || (req.Body != nil && req.GetBody == nil) {
+		policy.MaxAttempts = 1
+	}
+
+	ctx := req.Context()
+
+	for attempt := 1; ; attempt++ {
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		if throttled {
+			if err := c.rateLimit.wait(ctx); err != nil {
+				return nil, err
+			}
+		}
+
+		start := time.Now()
+		resp, err := c.doer().Do(req)
+		took := time.Since(start)
+
+		last := attempt >= policy.MaxAttempts
+		if err != nil {
+			c.logger.DebugContext(ctx, "request failed", "method", req.Method, "path", req.URL.Path, "latency", took, "attempt", attempt, "error", err)
+			if last || ctx.Err() != nil {
+				return nil, err
+			}
+
+			wait := policy.backoff(attempt)
+			c.logger.WarnContext(ctx, "retrying request", "method", req.Method, "path", req.URL.Path, "attempt", attempt, "wait", wait, "error", err)
+			if err := sleepCtx(ctx, wait); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		c.rateLimit.record(resp.Header)
+		c.logger.DebugContext(ctx, "request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "latency", took, "attempt", attempt)
+
+		if last || !policy.allowsStatus(resp.StatusCode) {
+			return resp, nil
+		}
+
+		wait, ok := serverDelay(resp)
+		if !ok {
+			wait = policy.backoff(attempt)
+		} else if policy.MaxDelay > 0 && wait > policy.MaxDelay {
+			// Not worth blocking this long, let the caller see the response.
+			c.logger.WarnContext(ctx, "server asked to wait longer than MaxDelay, not retrying", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "wait", wait)
+			return resp, nil
+		}
+
+		c.logger.WarnContext(ctx, "retrying request", "method", req.Method, "path", req.URL.Path, "status", resp.StatusCode, "attempt", attempt, "wait", wait)
+
+		// Drain so the connection can be reused.
+		io.Copy(io.Discard, io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		resp.Body.Close()
+
+		if err := sleepCtx(ctx, wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,160 @@
+package chawk_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+	"github.com/sugarvoid/chawk/chawktest"
+)
+
+func TestRetry(t *testing.T) {
+	policy := chawk.DefaultRetryPolicy()
+	policy.MaxAttempts = 3
+	policy.BaseDelay = time.Millisecond
+	policy.MaxDelay = 2 * time.Second
+
+	withPost := policy
+	withPost.RetryableMethods = append([]string{http.MethodPost}, policy.RetryableMethods...)
+
+	get := func(ctx context.Context, c *chawk.BlackboardClient) error {
+		_, err := c.Users.Get(ctx, chawk.ByUserName("jdoe"))
+		return err
+	}
+	create := func(ctx context.Context, c *chawk.BlackboardClient) error {
+		_, err := c.Users.CreateUserPro(ctx, chawk.User{UserName: "new"})
+		return err
+	}
+
+	tests := []struct {
+		name       string
+		policy     chawk.RetryPolicy
+		fault      chawktest.Fault
+		call       func(context.Context, *chawk.BlackboardClient) error
+		wantStatus int // 0 means the call should succeed
+		wantTries  int
+		minTime    time.Duration
+	}{
+		{
+			name:      "recovers",
+			policy:    policy,
+			fault:     chawktest.Fault{Method: "GET", Status: 503, Times: 2},
+			call:      get,
+			wantTries: 3,
+		},
+		{
+			name:       "gives up after MaxAttempts",
+			policy:     policy,
+			fault:      chawktest.Fault{Method: "GET", Status: 503},
+			call:       get,
+			wantStatus: 503,
+			wantTries:  3,
+		},
+		{
+			name:       "status not retryable",
+			policy:     policy,
+			fault:      chawktest.Fault{Method: "GET", Status: 500, Times: 1},
+			call:       get,
+			wantStatus: 500,
+			wantTries:  1,
+		},
+		{
+			name:      "honours Retry-After",
+			policy:    policy,
+			fault:     chawktest.Fault{Method: "GET", Status: 429, Header: http.Header{"Retry-After": {"1"}}, Times: 1},
+			call:      get,
+			wantTries: 2,
+			minTime:   time.Second,
+		},
+		{
+			name:       "Retry-After past MaxDelay",
+			policy:     policy,
+			fault:      chawktest.Fault{Method: "GET", Status: 503, Header: http.Header{"Retry-After": {"60"}}, Times: 1},
+			call:       get,
+			wantStatus: 503,
+			wantTries:  1,
+		},
+		{
+			name:       "retries off",
+			policy:     chawk.RetryPolicy{},
+			fault:      chawktest.Fault{Method: "GET", Status: 503, Times: 1},
+			call:       get,
+			wantStatus: 503,
+			wantTries:  1,
+		},
+		{
+			name:       "POST not retried",
+			policy:     policy,
+			fault:      chawktest.Fault{Method: "POST", Status: 503, Times: 1},
+			call:       create,
+			wantStatus: 503,
+			wantTries:  1,
+		},
+		{
+			name:      "POST opted in",
+			policy:    withPost,
+			fault:     chawktest.Fault{Method: "POST", Status: 503, Times: 1},
+			call:      create,
+			wantTries: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t, chawk.WithRetryPolicy(tt.policy))
+			ctx := context.Background()
+			srv.AddUser(chawk.User{UserName: "jdoe"})
+
+			// Get a token first so the fault only sees the call under test.
+			if _, err := client.Users.Get(ctx, chawk.ByUserName("jdoe")); err != nil {
+				t.Fatal(err)
+			}
+			before := len(srv.Requests())
+
+			tt.fault.Path = "/users"
+			srv.InjectFault(tt.fault)
+
+			start := time.Now()
+			err := tt.call(ctx, client)
+			took := time.Since(start)
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("err = %v, want success", err)
+				}
+			} else {
+				var apiErr *chawk.APIError
+				if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus {
+					t.Fatalf("err = %v, want an *APIError with status %d", err, tt.wantStatus)
+				}
+			}
+
+			if tries := len(srv.Requests()) - before; tries != tt.wantTries {
+				t.Errorf("sent %d requests, want %d", tries, tt.wantTries)
+			}
+			if took < tt.minTime {
+				t.Errorf("took %v, want at least %v", took, tt.minTime)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	policy := chawk.DefaultRetryPolicy()
+	policy.BaseDelay = time.Minute
+	policy.MaxDelay = time.Minute
+	srv, client := newTestServer(t, chawk.WithRetryPolicy(policy))
+	srv.AddUser(chawk.User{UserName: "jdoe"})
+	srv.InjectFault(chawktest.Fault{Method: "GET", Path: "/users", Status: 503})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := client.Users.Get(ctx, chawk.ByUserName("jdoe"))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want context.DeadlineExceeded", err)
+	}
+}
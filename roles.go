@@ -0,0 +1,258 @@
+package chawk
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+// RoleService reads the role catalogs: course roles (what a membership's
+// courseRoleId refers to), institution roles and system roles.
+type RoleService struct {
+	client *BlackboardClient
+
+	// CacheTTL is how long the catalogs used for validation are kept before
+	// they are fetched again. 0 means DefaultRoleCacheTTL.
+	CacheTTL time.Duration
+
+	mu    sync.Mutex
+	cache map[string]roleCatalog // by kind
+}
+
+// DefaultRoleCacheTTL is how long role catalogs are cached by default. Roles
+// are set up by admins and rarely change.
+const DefaultRoleCacheTTL = time.Hour
+
+// Kinds of role, as used in RoleError.
+const (
+	RoleKindCourse      = "course"
+	RoleKindInstitution = "institution"
+	RoleKindSystem      = "system"
+)
+
+type roleCatalog struct {
+	ids     []string
+	fetched time.Time
+}
+
+// CourseRole is a role a user can have in a course.
+type CourseRole struct {
+	ID                   string `json:"id"`
+	RoleID               string `json:"roleId"` // what memberships use, e.g. "Student"
+	NameForCourses       string `json:"nameForCourses"`
+	NameForOrganizations string `json:"nameForOrganizations"`
+	Description          string `json:"description"`
+	Custom               bool   `json:"custom"`
+	ActAsInstructor      bool   `json:"actAsInstructor"`
+	Availability         struct {
+		Available string `json:"available"`
+	} `json:"availability"`
+}
+
+// Role is an institution or system role.
+type Role struct {
+	ID          string `json:"id"`
+	RoleID      string `json:"roleId"` // what users' role lists use
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Custom      bool   `json:"custom"`
+}
+
+// RoleError is returned when a role ID isn't in the server's catalog. It
+// matches ErrInvalidRole with errors.Is.
+type RoleError struct {
+	Kind        string // RoleKindCourse, RoleKindInstitution or RoleKindSystem
+	Role        string
+	Suggestions []string // closest role IDs that do exist, best first
+}
+
+func (e *RoleError) Error() string {
+	msg := fmt.Sprintf("%s role %q doesn't exist", e.Kind, e.Role)
+	if len(e.Suggestions) > 0 {
+		msg += fmt.Sprintf(" (did you mean %s?)", strings.Join(e.Suggestions, ", "))
+	}
+	return msg
+}
+
+func (e *RoleError) Unwrap() error {
+	return ErrInvalidRole
+}
+
+// CourseRoles lists every course role, built in and custom.
+func (rs *RoleService) CourseRoles(ctx context.Context) ([]CourseRole, error) {
+	roles, err := collect(Paginate[CourseRole](ctx, rs.client, endpoints.Roles.CourseRoles()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course roles: %w", err)
+	}
+	return roles, nil
+}
+
+// InstitutionRoles lists every institution role.
+func (rs *RoleService) InstitutionRoles(ctx context.Context) ([]Role, error) {
+	roles, err := collect(Paginate[Role](ctx, rs.client, endpoints.Roles.InstitutionRoles()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get institution roles: %w", err)
+	}
+	return roles, nil
+}
+
+// SystemRoles lists every system role.
+func (rs *RoleService) SystemRoles(ctx context.Context) ([]Role, error) {
+	roles, err := collect(Paginate[Role](ctx, rs.client, endpoints.Roles.SystemRoles()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get system roles: %w", err)
+	}
+	return roles, nil
+}
+
+// ClearCache drops the cached catalogs, e.g. after adding a custom role.
+func (rs *RoleService) ClearCache() {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.cache = nil
+}
+
+// ValidateCourseRole returns a *RoleError if role isn't a course role.
+func (rs *RoleService) ValidateCourseRole(ctx context.Context, role string) error {
+	return rs.validate(ctx, RoleKindCourse, role)
+}
+
+// ValidateInstitutionRole returns a *RoleError if role isn't an institution
+// role.
+func (rs *RoleService) ValidateInstitutionRole(ctx context.Context, role string) error {
+	return rs.validate(ctx, RoleKindInstitution, role)
+}
+
+// ValidateSystemRole returns a *RoleError if role isn't a system role.
+func (rs *RoleService) ValidateSystemRole(ctx context.Context, role string) error {
+	return rs.validate(ctx, RoleKindSystem, role)
+}
+
+// validate checks role against the cached catalog of kind. If the catalog
+// can't be read (the app may not be allowed to), it warns and lets the role
+// through; Learn will still reject a bad one.
+func (rs *RoleService) validate(ctx context.Context, kind, role string) error {
+	ids, err := rs.catalog(ctx, kind)
+	if err != nil {
+		rs.client.logger.WarnContext(ctx, "skipping role validation", "kind", kind, "role", role, "error", err)
+		return nil
+	}
+
+	if slices.Contains(ids, role) {
+		return nil
+	}
+	return &RoleError{Kind: kind, Role: role, Suggestions: suggestRoles(role, ids)}
+}
+
+// catalog returns the role IDs of kind, fetching them if the cache is empty
+// or stale.
+func (rs *RoleService) catalog(ctx context.Context, kind string) ([]string, error) {
+	ttl := rs.CacheTTL
+	if ttl <= 0 {
+		ttl = DefaultRoleCacheTTL
+	}
+
+	rs.mu.Lock()
+	cached, ok := rs.cache[kind]
+	rs.mu.Unlock()
+	if ok && time.Since(cached.fetched) < ttl {
+		return cached.ids, nil
+	}
+
+	var ids []string
+	switch kind {
+	case RoleKindCourse:
+		roles, err := rs.CourseRoles(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range roles {
+			ids = append(ids, r.RoleID)
+		}
+	case RoleKindInstitution, RoleKindSystem:
+		list := rs.InstitutionRoles
+		if kind == RoleKindSystem {
+			list = rs.SystemRoles
+		}
+		roles, err := list(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range roles {
+			ids = append(ids, r.RoleID)
+		}
+	default:
+		return nil, fmt.Errorf("unknown role kind %q", kind)
+	}
+
+	rs.mu.Lock()
+	if rs.cache == nil {
+		rs.cache = map[string]roleCatalog{}
+	}
+	rs.cache[kind] = roleCatalog{ids: ids, fetched: time.Now()}
+	rs.mu.Unlock()
+
+	return ids, nil
+}
+
+// suggestRoles returns up to three IDs from ids that look like a typo of
+// role: same letters in another case, one containing the other, or a small
+// edit distance.
+func suggestRoles(role string, ids []string) []string {
+	type candidate struct {
+		id   string
+		dist int
+	}
+
+	lower := strings.ToLower(role)
+	var candidates []candidate
+	for _, id := range ids {
+		l := strings.ToLower(id)
+		switch {
+		case l == lower:
+			candidates = append(candidates, candidate{id, 0})
+		case lower != "" && (strings.Contains(l, lower) || strings.Contains(lower, l)):
+			candidates = append(candidates, candidate{id, 1})
+		default:
+			if d := editDistance(lower, l); d <= max(2, len(lower)/3) {
+				candidates = append(candidates, candidate{id, d})
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int { return a.dist - b.dist })
+
+	var out []string
+	for _, c := range candidates[:min(3, len(candidates))] {
+		out = append(out, c.id)
+	}
+	return out
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
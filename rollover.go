@@ -0,0 +1,435 @@
+package chawk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+// The steps a rollover takes for each course, in order.
+const (
+	RolloverCreate = "create" // create the empty course
+	RolloverCopy   = "copy"   // start copying the source into it
+	RolloverWait   = "wait"   // wait for the copy to finish
+	RolloverEnroll = "enroll" // enroll the instructors
+	RolloverDone   = "done"
+)
+
+// RolloverItem is one course to roll over: a new course made from source.
+type RolloverItem struct {
+	Line        int // line in the mapping file, 0 if not read from one
+	Source      string
+	CourseID    string
+	Name        string
+	TermID      string
+	Instructors []string // userNames
+}
+
+// RolloverPlan is the list of courses a rollover makes.
+type RolloverPlan struct {
+	Items []RolloverItem
+}
+
+// ReadRolloverPlan reads a mapping CSV with the columns source, courseId,
+// name, termId and instructors. source and courseId are course IDs;
+// instructors are userNames separated by ";" and can be left empty.
+//
+//	source,courseId,name,termId,instructors
+//	BIO-101-MASTER,BIO-101-2026FA,Biology 101,2026FA,jdoe;asmith
+func ReadRolloverPlan(r io.Reader) (*RolloverPlan, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	for _, col := range []string{"source", "courseId", "name", "termId"} {
+		if !table.has(col) {
+			return nil, fmt.Errorf("csv has no column %q", col)
+		}
+	}
+
+	plan := &RolloverPlan{}
+	for _, row := range table.rows {
+		item := RolloverItem{
+			Line:     row.line,
+			Source:   table.get(row, "source"),
+			CourseID: table.get(row, "courseId"),
+			Name:     table.get(row, "name"),
+			TermID:   table.get(row, "termId"),
+		}
+		item.Instructors = splitList(table.get(row, "instructors"))
+		plan.Items = append(plan.Items, item)
+	}
+
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// Validate checks that every item is filled in and that the new courseIds
+// are valid and not used twice.
+func (p *RolloverPlan) Validate() error {
+	seen := map[string]bool{}
+	var errs []error
+	for _, item := range p.Items {
+		where := fmt.Sprintf("line %d", item.Line)
+		if item.Line == 0 {
+			where = fmt.Sprintf("course %q", item.CourseID)
+		}
+
+		if item.Source == "" || item.CourseID == "" || item.Name == "" || item.TermID == "" {
+			errs = append(errs, fmt.Errorf("%s: source, courseId, name and termId are required", where))
+			continue
+		}
+		if err := ValidateCourseID(item.CourseID); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+		}
+		if seen[item.CourseID] {
+			errs = append(errs, fmt.Errorf("%s: courseId %q is used twice", where, item.CourseID))
+		}
+		seen[item.CourseID] = true
+	}
+	return errors.Join(errs...)
+}
+
+// RolloverOptions controls Rollover.
+type RolloverOptions struct {
+	// Copy is what is copied from each source. nil means ExistingCourseCopy,
+	// since the course is created first.
+	Copy *CopyOptions
+
+	// Checkpoint is a file where progress is saved after every step. Run
+	// the same plan again with the same file and finished steps are
+	// skipped. Empty means no checkpoint.
+	Checkpoint string
+
+	// Concurrency is how many courses are rolled over at once, see
+	// DefaultConcurrency.
+	Concurrency int
+
+	// PollInterval is passed to WaitForTask.
+	PollInterval time.Duration
+
+	// InstructorRole is the course role instructors get. "" means
+	// RoleInstructor.
+	InstructorRole string
+}
+
+// RolloverResult is the outcome for one course.
+type RolloverResult struct {
+	Line     int
+	CourseID string
+	Step     string // RolloverDone, or the step that failed
+	Resumed  bool   // some steps were already done by an earlier run
+	Err      error
+}
+
+// RolloverReport has one result per plan item, in plan order.
+type RolloverReport struct {
+	Results []RolloverResult
+}
+
+// Failed returns the courses that didn't finish.
+func (r *RolloverReport) Failed() []RolloverResult {
+	var out []RolloverResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// rolloverState is what the checkpoint keeps for one course.
+//
+// Creating and Copying are written before the call that creates or copies,
+// so a run that died between the call and saving its result can tell that
+// the course or the copy may already be there.
+type rolloverState struct {
+	Creating bool     `json:"creating,omitempty"`
+	Created  bool     `json:"created,omitempty"`
+	Copying  bool     `json:"copying,omitempty"`
+	TaskURI  string   `json:"taskUri,omitempty"`
+	Copied   bool     `json:"copied,omitempty"`
+	Enrolled []string `json:"enrolled,omitempty"`
+	Done     bool     `json:"done,omitempty"`
+}
+
+// rolloverCheckpoint is the checkpoint file, keyed by new courseId.
+type rolloverCheckpoint struct {
+	path string
+	mu   sync.Mutex
+
+	Courses map[string]*rolloverState `json:"courses"`
+}
+
+func loadRolloverCheckpoint(path string) (*rolloverCheckpoint, error) {
+	cp := &rolloverCheckpoint{path: path, Courses: map[string]*rolloverState{}}
+	if path == "" {
+		return cp, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+	if err := json.Unmarshal(data, cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	if cp.Courses == nil {
+		cp.Courses = map[string]*rolloverState{}
+	}
+	return cp, nil
+}
+
+// state returns a copy of courseID's state.
+func (cp *rolloverCheckpoint) state(courseID string) rolloverState {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if s, ok := cp.Courses[courseID]; ok {
+		return *s
+	}
+	return rolloverState{}
+}
+
+// update changes courseID's state and writes the file.
+func (cp *rolloverCheckpoint) update(courseID string, fn func(*rolloverState)) error {
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	s, ok := cp.Courses[courseID]
+	if !ok {
+		s = &rolloverState{}
+		cp.Courses[courseID] = s
+	}
+	fn(s)
+
+	if cp.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	// Write next to the real file and rename, so a crash never leaves half
+	// a checkpoint.
+	dir := filepath.Dir(cp.path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(cp.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp checkpoint: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), cp.path); err != nil {
+		return fmt.Errorf("failed to replace checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Rollover makes every course in plan: it creates the course, copies the
+// source into it, waits for the copy and enrolls the instructors. Courses
+// are worked on in parallel; each stops at its first failure and the rest
+// carry on.
+//
+// With a checkpoint, a failed run can be started again and will pick up
+// where each course left off. A copy that was started is waited on rather
+// than started again, so content is never copied twice; if a run died
+// before saving the copy's task, the new course's tasks are checked for it.
+// A course that already exists fails at the create step, so nothing is
+// copied over a course made some other way, unless the checkpoint shows an
+// earlier run was creating it and its name and termId match the item.
+//
+// The returned error is only for problems with the plan or checkpoint file.
+func (cs *CourseService) Rollover(ctx context.Context, plan *RolloverPlan, opts RolloverOptions) (*RolloverReport, error) {
+	if err := plan.Validate(); err != nil {
+		return nil, err
+	}
+
+	cp, err := loadRolloverCheckpoint(opts.Checkpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	copyOpts := ExistingCourseCopy()
+	if opts.Copy != nil {
+		copyOpts = *opts.Copy
+	}
+	role := opts.InstructorRole
+	if role == "" {
+		role = RoleInstructor
+	}
+
+	report := &RolloverReport{Results: make([]RolloverResult, len(plan.Items))}
+	forEach(ctx, len(plan.Items), opts.Concurrency, func(i int, err error) {
+		item := plan.Items[i]
+		res := RolloverResult{Line: item.Line, CourseID: item.CourseID}
+		if err != nil {
+			res.Step, res.Err = RolloverCreate, err
+		} else {
+			res.Step, res.Err = cs.rolloverOne(ctx, item, cp, copyOpts, role, opts.PollInterval, &res.Resumed)
+		}
+		report.Results[i] = res
+	})
+
+	cs.client.logger.InfoContext(ctx, "rollover finished", "courses", len(report.Results), "failed", len(report.Failed()))
+	return report, nil
+}
+
+// rolloverOne runs the steps for item that the checkpoint doesn't have yet.
+// It returns the step it stopped at.
+func (cs *CourseService) rolloverOne(ctx context.Context, item RolloverItem, cp *rolloverCheckpoint, copyOpts CopyOptions, role string, poll time.Duration, resumed *bool) (string, error) {
+	st := cp.state(item.CourseID)
+	*resumed = st.Created || st.Creating
+	course := ByCourseID(item.CourseID)
+
+	if st.Done {
+		return RolloverDone, nil
+	}
+
+	if !st.Created {
+		if err := cp.update(item.CourseID, func(s *rolloverState) { s.Creating = true }); err != nil {
+			return RolloverCreate, err
+		}
+
+		_, err := cs.Create(ctx, item.CourseID, item.Name, item.TermID)
+		if errors.Is(err, ErrCourseExist) && st.Creating {
+			err = cs.adoptRolloverCourse(ctx, item)
+		}
+		if err != nil {
+			return RolloverCreate, fmt.Errorf("failed to create %s: %w", item.CourseID, err)
+		}
+		if err := cp.update(item.CourseID, func(s *rolloverState) { s.Created = true }); err != nil {
+			return RolloverCreate, err
+		}
+	}
+
+	if st.TaskURI == "" && !st.Copied && st.Copying {
+		uri, err := cs.findCopyTask(ctx, course)
+		if err != nil {
+			return RolloverCopy, fmt.Errorf("failed to look for an earlier copy into %s: %w", item.CourseID, err)
+		}
+		if uri != "" {
+			cs.client.logger.InfoContext(ctx, "picking up earlier copy", "course", item.CourseID, "task", uri)
+			st.TaskURI = uri
+			if err := cp.update(item.CourseID, func(s *rolloverState) { s.TaskURI = uri }); err != nil {
+				return RolloverCopy, err
+			}
+		}
+	}
+
+	if st.TaskURI == "" && !st.Copied {
+		if err := cp.update(item.CourseID, func(s *rolloverState) { s.Copying = true }); err != nil {
+			return RolloverCopy, err
+		}
+
+		uri, err := cs.CopyIntoCourse(ctx, ByCourseID(item.Source), course, copyOpts)
+		if err != nil {
+			return RolloverCopy, fmt.Errorf("failed to copy %s into %s: %w", item.Source, item.CourseID, err)
+		}
+		st.TaskURI = uri
+		if err := cp.update(item.CourseID, func(s *rolloverState) { s.TaskURI = uri }); err != nil {
+			return RolloverCopy, err
+		}
+	}
+
+	if !st.Copied {
+		if _, err := cs.WaitForTask(ctx, st.TaskURI, poll); err != nil {
+			err = fmt.Errorf("copy of %s into %s didn't finish: %w", item.Source, item.CourseID, err)
+			if errors.Is(err, ErrTaskFailed) {
+				// Let the next run copy again rather than wait on a dead task.
+				if cerr := cp.update(item.CourseID, func(s *rolloverState) { s.TaskURI, s.Copying = "", false }); cerr != nil {
+					err = errors.Join(err, cerr)
+				}
+			}
+			return RolloverWait, err
+		}
+		if err := cp.update(item.CourseID, func(s *rolloverState) { s.Copied = true }); err != nil {
+			return RolloverWait, err
+		}
+	}
+
+	for _, user := range item.Instructors {
+		if slices.Contains(st.Enrolled, user) {
+			continue
+		}
+
+		err := cs.EnrollUserIntoCourse(ctx, course, ByUserName(user), role, AvailabilityYes)
+		if err != nil && !errors.Is(err, ErrUserAlreadyEnrolled) {
+			return RolloverEnroll, fmt.Errorf("failed to enroll %s in %s: %w", user, item.CourseID, err)
+		}
+		if err := cp.update(item.CourseID, func(s *rolloverState) { s.Enrolled = append(s.Enrolled, user) }); err != nil {
+			return RolloverEnroll, err
+		}
+	}
+
+	if err := cp.update(item.CourseID, func(s *rolloverState) { s.Done = true }); err != nil {
+		return RolloverEnroll, err
+	}
+	cs.client.logger.InfoContext(ctx, "course rolled over", "source", item.Source, "course", item.CourseID)
+	return RolloverDone, nil
+}
+
+// adoptRolloverCourse checks that the existing course item.CourseID is the
+// one an earlier run created, by its name and termId.
+func (cs *CourseService) adoptRolloverCourse(ctx context.Context, item RolloverItem) error {
+	c, err := cs.Get(ctx, ByCourseID(item.CourseID))
+	if err != nil {
+		return err
+	}
+	if c.Name != strings.TrimSpace(item.Name) || c.TermID != strings.TrimSpace(item.TermID) {
+		return fmt.Errorf("course exists with name %q and termId %q, not the plan's: %w", c.Name, c.TermID, ErrCourseExist)
+	}
+
+	cs.client.logger.InfoContext(ctx, "picking up course created by earlier run", "course", item.CourseID)
+	return nil
+}
+
+// findCopyTask returns the URI of course's latest task, or "" if it has
+// none or the latest one failed.
+func (cs *CourseService) findCopyTask(ctx context.Context, course CourseRef) (string, error) {
+	c, err := cs.Get(ctx, course)
+	if err != nil {
+		return "", err
+	}
+	tasks, err := cs.ListTasks(ctx, ByPrimaryID(c.ID))
+	if err != nil {
+		return "", err
+	}
+	if len(tasks) == 0 {
+		return "", nil
+	}
+
+	latest := tasks[0]
+	for _, t := range tasks[1:] {
+		if !t.Queued.Before(latest.Queued) {
+			latest = t
+		}
+	}
+	if latest.Done() && latest.Status != TaskComplete {
+		return "", nil
+	}
+	return endpoints.Courses.GetTask(c.ID, latest.ID), nil
+}
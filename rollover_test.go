@@ -0,0 +1,187 @@
+package chawk_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sugarvoid/chawk"
+	"github.com/sugarvoid/chawk/chawktest"
+)
+
+const rolloverCSV = "source,courseId,name,termId,instructors\nBIO-MASTER,BIO-101-FA,Biology 101,FA,prof\n"
+
+// countCopies returns how many copies the server was asked to start.
+func countCopies(srv *chawktest.Server) int {
+	n := 0
+	for _, r := range srv.Requests() {
+		if r.Method == "POST" && strings.HasSuffix(r.Path, "/copy") {
+			n++
+		}
+	}
+	return n
+}
+
+func newRolloverServer(t *testing.T) (*chawktest.Server, *chawk.BlackboardClient, *chawk.RolloverPlan) {
+	t.Helper()
+	srv, client := newTestServer(t)
+	srv.AddCourse(chawk.Course{CourseID: "BIO-MASTER", Name: "Biology master", TermID: "MASTER"})
+	srv.AddUser(chawk.User{UserName: "prof"})
+
+	plan, err := chawk.ReadRolloverPlan(strings.NewReader(rolloverCSV))
+	mustDo(t, err)
+	return srv, client, plan
+}
+
+func TestRolloverResume(t *testing.T) {
+	tests := []struct {
+		name       string
+		checkpoint string // what an earlier run left behind, "" for none
+		setup      func(t *testing.T, srv *chawktest.Server, client *chawk.BlackboardClient)
+		wantStep   string
+		wantErr    error
+		wantCopies int // copies started by this run
+		resumed    bool
+	}{
+		{
+			name:       "fresh",
+			wantStep:   chawk.RolloverDone,
+			wantCopies: 1,
+		},
+		{
+			name:       "done",
+			checkpoint: `{"courses":{"BIO-101-FA":{"created":true,"copied":true,"done":true}}}`,
+			wantStep:   chawk.RolloverDone,
+			resumed:    true,
+		},
+		{
+			name: "course made some other way",
+			setup: func(t *testing.T, srv *chawktest.Server, client *chawk.BlackboardClient) {
+				srv.AddCourse(chawk.Course{CourseID: "BIO-101-FA", Name: "Biology 101", TermID: "FA"})
+			},
+			wantStep: chawk.RolloverCreate,
+			wantErr:  chawk.ErrCourseExist,
+		},
+		{
+			name:       "died after create",
+			checkpoint: `{"courses":{"BIO-101-FA":{"creating":true}}}`,
+			setup: func(t *testing.T, srv *chawktest.Server, client *chawk.BlackboardClient) {
+				srv.AddCourse(chawk.Course{CourseID: "BIO-101-FA", Name: "Biology 101", TermID: "FA"})
+			},
+			wantStep:   chawk.RolloverDone,
+			wantCopies: 1,
+			resumed:    true,
+		},
+		{
+			name:       "died after create of a different course",
+			checkpoint: `{"courses":{"BIO-101-FA":{"creating":true}}}`,
+			setup: func(t *testing.T, srv *chawktest.Server, client *chawk.BlackboardClient) {
+				srv.AddCourse(chawk.Course{CourseID: "BIO-101-FA", Name: "Chemistry", TermID: "FA"})
+			},
+			wantStep: chawk.RolloverCreate,
+			wantErr:  chawk.ErrCourseExist,
+			resumed:  true,
+		},
+		{
+			name:       "died before create",
+			checkpoint: `{"courses":{"BIO-101-FA":{"creating":true}}}`,
+			wantStep:   chawk.RolloverDone,
+			wantCopies: 1,
+			resumed:    true,
+		},
+		{
+			name:       "died after starting copy",
+			checkpoint: `{"courses":{"BIO-101-FA":{"creating":true,"created":true,"copying":true}}}`,
+			setup: func(t *testing.T, srv *chawktest.Server, client *chawk.BlackboardClient) {
+				srv.AddCourse(chawk.Course{CourseID: "BIO-101-FA", Name: "Biology 101", TermID: "FA"})
+				_, err := client.Courses.CopyIntoCourse(context.Background(), chawk.ByCourseID("BIO-MASTER"), chawk.ByCourseID("BIO-101-FA"), chawk.ExistingCourseCopy())
+				mustDo(t, err)
+			},
+			wantStep: chawk.RolloverDone,
+			resumed:  true,
+		},
+		{
+			name:       "died before starting copy",
+			checkpoint: `{"courses":{"BIO-101-FA":{"creating":true,"created":true,"copying":true}}}`,
+			setup: func(t *testing.T, srv *chawktest.Server, client *chawk.BlackboardClient) {
+				srv.AddCourse(chawk.Course{CourseID: "BIO-101-FA", Name: "Biology 101", TermID: "FA"})
+			},
+			wantStep:   chawk.RolloverDone,
+			wantCopies: 1,
+			resumed:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client, plan := newRolloverServer(t)
+			path := filepath.Join(t.TempDir(), "checkpoint.json")
+			if tt.checkpoint != "" {
+				mustDo(t, os.WriteFile(path, []byte(tt.checkpoint), 0o600))
+			}
+			if tt.setup != nil {
+				tt.setup(t, srv, client)
+			}
+			before := countCopies(srv)
+
+			report, err := client.Courses.Rollover(context.Background(), plan, chawk.RolloverOptions{Checkpoint: path})
+			if err != nil {
+				t.Fatalf("Rollover: %v", err)
+			}
+
+			res := report.Results[0]
+			if res.Step != tt.wantStep || !errors.Is(res.Err, tt.wantErr) || (tt.wantErr == nil && res.Err != nil) {
+				t.Fatalf("step %s, err %v; want %s, %v", res.Step, res.Err, tt.wantStep, tt.wantErr)
+			}
+			if res.Resumed != tt.resumed {
+				t.Errorf("Resumed = %v, want %v", res.Resumed, tt.resumed)
+			}
+			if got := countCopies(srv) - before; got != tt.wantCopies {
+				t.Errorf("started %d copies, want %d", got, tt.wantCopies)
+			}
+			if _, exists := srv.Course("BIO-101-FA"); exists && tt.wantStep == chawk.RolloverDone {
+				if m, ok := srv.Membership("BIO-101-FA", "prof"); !ok || m.CourseRoleID != chawk.RoleInstructor {
+					t.Errorf("prof not enrolled as instructor: %+v", m)
+				}
+			}
+		})
+	}
+}
+
+func TestRolloverFailedCopyIsRetried(t *testing.T) {
+	srv, client, plan := newRolloverServer(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	opts := chawk.RolloverOptions{Checkpoint: path}
+
+	srv.InjectFault(chawktest.Fault{Method: "GET", Path: "/tasks/", Status: 200, Body: `{"id":"_1_1","status":"Failed"}`})
+	report, err := client.Courses.Rollover(ctx, plan, opts)
+	mustDo(t, err)
+	if res := report.Results[0]; res.Step != chawk.RolloverWait || !errors.Is(res.Err, chawk.ErrTaskFailed) {
+		t.Fatalf("first run: step %s, err %v", res.Step, res.Err)
+	}
+
+	var cp struct {
+		Courses map[string]map[string]any `json:"courses"`
+	}
+	data, err := os.ReadFile(path)
+	mustDo(t, err)
+	mustDo(t, json.Unmarshal(data, &cp))
+	if uri, ok := cp.Courses["BIO-101-FA"]["taskUri"]; ok {
+		t.Errorf("checkpoint still has task %v", uri)
+	}
+
+	srv.ClearFaults()
+	report, err = client.Courses.Rollover(ctx, plan, opts)
+	mustDo(t, err)
+	if res := report.Results[0]; res.Err != nil {
+		t.Fatalf("second run: %v", res.Err)
+	}
+	if n := countCopies(srv); n != 2 {
+		t.Errorf("%d copies, want 2", n)
+	}
+}
@@ -0,0 +1,373 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// RosterAction is one kind of change PlanRoster can make to a membership.
+type RosterAction string
+
+const (
+	RosterAdd          RosterAction = "add"          // enroll someone who isn't in the course
+	RosterRole         RosterAction = "role"         // change a member's course role
+	RosterAvailability RosterAction = "availability" // change a member's availability
+	RosterDisable      RosterAction = "disable"      // set a member who isn't on the roster to AvailabilityDisabled
+	RosterRemove       RosterAction = "remove"       // take a member who isn't on the roster out of the course
+)
+
+// RosterEntry is one person who should be in the course.
+type RosterEntry struct {
+	Line      int // line in the roster file, 0 if not read from one
+	UserName  string
+	Role      string // a course role ID, "" means RoleStudent
+	Available string // AvailabilityYes, AvailabilityNo or AvailabilityDisabled, "" means AvailabilityYes
+}
+
+// ReadRoster reads a roster CSV with a userName column and optional role
+// and availability columns. Empty cells get the RosterEntry defaults.
+//
+//	userName,role,availability
+//	jdoe,Student,Yes
+//	asmith,TeachingAssistant,
+func ReadRoster(r io.Reader) ([]RosterEntry, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+	if !table.has("userName") {
+		return nil, errors.New(`csv has no column "userName"`)
+	}
+
+	var entries []RosterEntry
+	for _, row := range table.rows {
+		entries = append(entries, RosterEntry{
+			Line:      row.line,
+			UserName:  table.get(row, "userName"),
+			Role:      table.get(row, "role"),
+			Available: table.get(row, "availability"),
+		})
+	}
+	return entries, nil
+}
+
+// RosterOptions controls PlanRoster.
+type RosterOptions struct {
+	// Missing is what happens to members who aren't on the roster:
+	// RosterDisable (the default) or RosterRemove. Disabling sets their
+	// availability to AvailabilityDisabled, as Offboard does, and keeps
+	// their grades and submissions. Members who are already No or Disabled
+	// are counted as unchanged, so a hand-hidden member isn't touched.
+	Missing RosterAction
+
+	// ManagedRoles limits Missing to members with one of these roles, so
+	// people enrolled by hand (instructors, say) are left alone. nil means
+	// every member.
+	ManagedRoles []string
+}
+
+// RosterChange is one change to one membership.
+type RosterChange struct {
+	Action   RosterAction
+	UserName string
+	Line     int // the roster line asking for it, 0 for RosterDisable and RosterRemove
+
+	// Old is what the membership has now and New what it gets; empty when
+	// there is no such side, e.g. Old on an add.
+	OldRole, NewRole           string
+	OldAvailable, NewAvailable string
+}
+
+func (c RosterChange) String() string {
+	switch c.Action {
+	case RosterAdd:
+		return fmt.Sprintf("add %s as %s (%s)", c.UserName, c.NewRole, c.NewAvailable)
+	case RosterRole:
+		return fmt.Sprintf("change %s from %s to %s", c.UserName, c.OldRole, c.NewRole)
+	case RosterAvailability, RosterDisable:
+		return fmt.Sprintf("change %s from %s to %s", c.UserName, c.OldAvailable, c.NewAvailable)
+	default:
+		return fmt.Sprintf("%s %s", c.Action, c.UserName)
+	}
+}
+
+// RosterPlan is the list of changes that makes a course match a roster.
+// Nothing is changed until it's passed to ApplyRoster, so it can be shown
+// or checked first.
+type RosterPlan struct {
+	Course    CourseRef
+	Changes   []RosterChange
+	Unchanged int // members that already match
+	Skipped   int // members enrolled through a child course, see PlanRoster
+}
+
+// Count returns how many changes are action.
+func (p *RosterPlan) Count(action RosterAction) int {
+	n := 0
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// PlanRoster compares course's memberships with entries and works out the
+// changes. userNames are matched ignoring case. Members enrolled through a
+// child course are never added, changed, disabled or removed, since their
+// enrollment lives in the child; they are counted in Skipped instead.
+//
+// An error means the roster itself is bad (blank or repeated userNames,
+// unknown roles) or the course couldn't be read.
+func (cs *CourseService) PlanRoster(ctx context.Context, course CourseRef, entries []RosterEntry, opts RosterOptions) (*RosterPlan, error) {
+	switch opts.Missing {
+	case "":
+		opts.Missing = RosterDisable
+	case RosterDisable, RosterRemove:
+	default:
+		return nil, fmt.Errorf("invalid Missing action %q", opts.Missing)
+	}
+
+	desired, err := cs.checkRoster(ctx, entries)
+	if err != nil {
+		return nil, err
+	}
+
+	current := map[string]CourseMembership{}
+	inChild := map[string]bool{}
+	for m, err := range cs.ListMemberships(ctx, course, MembershipQuery{ExpandUser: true}) {
+		if err != nil {
+			return nil, err
+		}
+		if m.User == nil {
+			continue
+		}
+		key := strings.ToLower(m.User.UserName)
+		if m.ChildCourseID != nil && *m.ChildCourseID != "" {
+			inChild[key] = true
+			continue
+		}
+		current[key] = m
+	}
+
+	plan := &RosterPlan{Course: course, Skipped: len(inChild)}
+	for _, e := range desired {
+		key := strings.ToLower(e.UserName)
+		if inChild[key] {
+			continue
+		}
+		m, ok := current[key]
+		if !ok {
+			plan.Changes = append(plan.Changes, RosterChange{
+				Action:       RosterAdd,
+				UserName:     e.UserName,
+				Line:         e.Line,
+				NewRole:      e.Role,
+				NewAvailable: e.Available,
+			})
+			continue
+		}
+
+		role, available := deref(m.CourseRoleID), ""
+		if m.Availability != nil {
+			available = deref(m.Availability.Available)
+		}
+
+		changed := false
+		if role != e.Role {
+			plan.Changes = append(plan.Changes, RosterChange{
+				Action:   RosterRole,
+				UserName: m.User.UserName,
+				Line:     e.Line,
+				OldRole:  role,
+				NewRole:  e.Role,
+			})
+			changed = true
+		}
+		if available != e.Available {
+			plan.Changes = append(plan.Changes, RosterChange{
+				Action:       RosterAvailability,
+				UserName:     m.User.UserName,
+				Line:         e.Line,
+				OldAvailable: available,
+				NewAvailable: e.Available,
+			})
+			changed = true
+		}
+		if !changed {
+			plan.Unchanged++
+		}
+	}
+
+	onRoster := map[string]bool{}
+	for _, e := range desired {
+		onRoster[strings.ToLower(e.UserName)] = true
+	}
+
+	// Sorted so the same roster always gives the same plan.
+	missing := []string{}
+	for key := range current {
+		if !onRoster[key] {
+			missing = append(missing, key)
+		}
+	}
+	slices.Sort(missing)
+
+	for _, key := range missing {
+		m := current[key]
+		role := deref(m.CourseRoleID)
+		if opts.ManagedRoles != nil && !slices.Contains(opts.ManagedRoles, role) {
+			continue
+		}
+
+		available := ""
+		if m.Availability != nil {
+			available = deref(m.Availability.Available)
+		}
+		change := RosterChange{Action: opts.Missing, UserName: m.User.UserName, OldRole: role, OldAvailable: available}
+
+		if opts.Missing == RosterDisable {
+			if available == AvailabilityNo || available == AvailabilityDisabled {
+				plan.Unchanged++
+				continue
+			}
+			change.NewAvailable = AvailabilityDisabled
+		}
+		plan.Changes = append(plan.Changes, change)
+	}
+
+	cs.client.logger.InfoContext(ctx, "roster planned", "course", course,
+		"add", plan.Count(RosterAdd),
+		"role", plan.Count(RosterRole),
+		"availability", plan.Count(RosterAvailability),
+		"disable", plan.Count(RosterDisable),
+		"remove", plan.Count(RosterRemove),
+		"unchanged", plan.Unchanged,
+		"skipped", plan.Skipped)
+
+	return plan, nil
+}
+
+// checkRoster fills in the defaults and checks every entry, returning all
+// the problems at once.
+func (cs *CourseService) checkRoster(ctx context.Context, entries []RosterEntry) ([]RosterEntry, error) {
+	out := make([]RosterEntry, 0, len(entries))
+	seen := map[string]bool{}
+	checkedRoles := map[string]error{}
+	var errs []error
+
+	for _, e := range entries {
+		e.UserName = strings.TrimSpace(e.UserName)
+		e.Role = strings.TrimSpace(e.Role)
+		e.Available = strings.TrimSpace(e.Available)
+		if e.Role == "" {
+			e.Role = RoleStudent
+		}
+		if e.Available == "" {
+			e.Available = AvailabilityYes
+		}
+
+		where := fmt.Sprintf("line %d", e.Line)
+		if e.Line == 0 {
+			where = fmt.Sprintf("user %q", e.UserName)
+		}
+
+		if e.UserName == "" {
+			errs = append(errs, fmt.Errorf("%s: %w", where, ErrInvalidUsername))
+			continue
+		}
+		key := strings.ToLower(e.UserName)
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("%s: userName %q is listed twice", where, e.UserName))
+		}
+		seen[key] = true
+
+		switch e.Available {
+		case AvailabilityYes, AvailabilityNo, AvailabilityDisabled:
+		default:
+			errs = append(errs, fmt.Errorf("%s: invalid availability %q", where, e.Available))
+		}
+
+		err, ok := checkedRoles[e.Role]
+		if !ok {
+			err = cs.client.Roles.ValidateCourseRole(ctx, e.Role)
+			checkedRoles[e.Role] = err
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", where, err))
+		}
+
+		out = append(out, e)
+	}
+
+	return out, errors.Join(errs...)
+}
+
+// RosterResult is the outcome of one change.
+type RosterResult struct {
+	RosterChange
+	Err error // usually an *APIError
+}
+
+// RosterReport has one result per change, in plan order.
+type RosterReport struct {
+	Results []RosterResult
+}
+
+// Failed returns the changes that weren't made.
+func (r *RosterReport) Failed() []RosterResult {
+	var out []RosterResult
+	for _, res := range r.Results {
+		if res.Err != nil {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// ApplyRoster makes the changes in plan, concurrency of them at a time (see
+// DefaultConcurrency). A change that fails doesn't stop the others.
+//
+//	plan, err := client.Courses.PlanRoster(ctx, course, entries, chawk.RosterOptions{})
+//	...
+//	report := client.Courses.ApplyRoster(ctx, plan, 0)
+func (cs *CourseService) ApplyRoster(ctx context.Context, plan *RosterPlan, concurrency int) *RosterReport {
+	report := &RosterReport{Results: make([]RosterResult, len(plan.Changes))}
+	forEach(ctx, len(plan.Changes), concurrency, func(i int, err error) {
+		change := plan.Changes[i]
+		if err == nil {
+			err = cs.applyRosterChange(ctx, plan.Course, change)
+		}
+		report.Results[i] = RosterResult{RosterChange: change, Err: err}
+	})
+
+	cs.client.logger.InfoContext(ctx, "roster applied", "course", plan.Course, "changes", len(report.Results), "failed", len(report.Failed()))
+	return report
+}
+
+func (cs *CourseService) applyRosterChange(ctx context.Context, course CourseRef, c RosterChange) error {
+	user := ByUserName(c.UserName)
+
+	var err error
+	switch c.Action {
+	case RosterAdd:
+		err = cs.EnrollUserIntoCourse(ctx, course, user, c.NewRole, c.NewAvailable)
+	case RosterRole:
+		err = cs.UpdateMembership(ctx, user, course, EnrollmentRequest{CourseRoleID: ToPtr(c.NewRole)})
+	case RosterAvailability, RosterDisable:
+		err = cs.UpdateMembershipAvailability(ctx, user, course, c.NewAvailable)
+	case RosterRemove:
+		err = cs.RemoveUser(ctx, course, user)
+	default:
+		err = fmt.Errorf("unknown roster action %q", c.Action)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to %s: %w", c, err)
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package chawk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func TestPlanRoster(t *testing.T) {
+	tests := []struct {
+		name      string
+		roster    string
+		opts      chawk.RosterOptions
+		want      []string // RosterChange.String of every change, in order
+		unchanged int
+	}{
+		{
+			name:      "matches",
+			roster:    "userName,role,availability\nkeep,,\nta,TeachingAssistant,\ngone,,\nhidden,,No\nprof,Instructor,\n",
+			unchanged: 5,
+		},
+		{
+			name:   "changes",
+			roster: "userName,role,availability\nkeep,,\nta,Student,No\nnew,,\nprof,Instructor,\n",
+			want: []string{
+				"change ta from TeachingAssistant to Student",
+				"change ta from Yes to No",
+				"add new as Student (Yes)",
+				"change gone from Yes to Disabled",
+			},
+			// keep and prof; hidden is already No
+			unchanged: 3,
+		},
+		{
+			name:   "managed roles leave staff alone",
+			roster: "userName\nkeep\n",
+			opts:   chawk.RosterOptions{ManagedRoles: []string{chawk.RoleStudent}},
+			want:   []string{"change gone from Yes to Disabled"},
+			// keep and hidden
+			unchanged: 2,
+		},
+		{
+			name:      "remove",
+			roster:    "userName,role\nkeep,\nprof,Instructor\nta,TeachingAssistant\n",
+			opts:      chawk.RosterOptions{Missing: chawk.RosterRemove},
+			want:      []string{"remove gone", "remove hidden"},
+			unchanged: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t)
+			ctx := context.Background()
+			course := chawk.ByCourseID("BIO-101")
+
+			srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+			for _, u := range []string{"keep", "ta", "gone", "hidden", "prof", "new"} {
+				srv.AddUser(chawk.User{UserName: u})
+			}
+			mustDo(t, srv.Enroll("BIO-101", "keep", ""))
+			mustDo(t, srv.Enroll("BIO-101", "ta", chawk.RoleTA))
+			mustDo(t, srv.Enroll("BIO-101", "gone", ""))
+			mustDo(t, srv.Enroll("BIO-101", "hidden", ""))
+			mustDo(t, srv.Enroll("BIO-101", "prof", chawk.RoleInstructor))
+			mustDo(t, client.Courses.UpdateMembershipAvailability(ctx, chawk.ByUserName("hidden"), course, chawk.AvailabilityNo))
+
+			entries, err := chawk.ReadRoster(strings.NewReader(tt.roster))
+			mustDo(t, err)
+			plan, err := client.Courses.PlanRoster(ctx, course, entries, tt.opts)
+			if err != nil {
+				t.Fatalf("PlanRoster: %v", err)
+			}
+
+			var got []string
+			for _, c := range plan.Changes {
+				got = append(got, c.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("changes:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+			if plan.Unchanged != tt.unchanged {
+				t.Errorf("Unchanged = %d, want %d", plan.Unchanged, tt.unchanged)
+			}
+		})
+	}
+}
+
+func TestPlanRosterDisabledIsUnchanged(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+	course := chawk.ByCourseID("BIO-101")
+
+	srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+	srv.AddUser(chawk.User{UserName: "c"})
+	mustDo(t, srv.Enroll("BIO-101", "c", ""))
+	mustDo(t, client.Courses.UpdateMembershipAvailability(ctx, chawk.ByUserName("c"), course, chawk.AvailabilityDisabled))
+
+	plan, err := client.Courses.PlanRoster(ctx, course, nil, chawk.RosterOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Changes) != 0 || plan.Unchanged != 1 {
+		t.Errorf("changes %v, unchanged %d; want none and 1", plan.Changes, plan.Unchanged)
+	}
+}
+
+func TestPlanRosterChildCourse(t *testing.T) {
+	for _, missing := range []chawk.RosterAction{chawk.RosterDisable, chawk.RosterRemove} {
+		t.Run(string(missing), func(t *testing.T) {
+			srv, client := newTestServer(t)
+			ctx := context.Background()
+			course := chawk.ByCourseID("BIO-101")
+
+			srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+			child := srv.AddCourse(chawk.Course{CourseID: "BIO-101-S2", Name: "Biology, section 2"})
+			for _, u := range []string{"direct", "listed", "unlisted"} {
+				srv.AddUser(chawk.User{UserName: u})
+				mustDo(t, srv.Enroll("BIO-101", u, ""))
+			}
+			// Enrolled through the child section.
+			for _, u := range []string{"listed", "unlisted"} {
+				mustDo(t, client.Courses.UpdateMembership(ctx, chawk.ByUserName(u), course, chawk.EnrollmentRequest{ChildCourseID: chawk.ToPtr(child.ID)}))
+			}
+
+			entries := []chawk.RosterEntry{{UserName: "direct"}, {UserName: "LISTED", Role: chawk.RoleTA}}
+			plan, err := client.Courses.PlanRoster(ctx, course, entries, chawk.RosterOptions{Missing: missing})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(plan.Changes) != 0 || plan.Unchanged != 1 || plan.Skipped != 2 {
+				t.Errorf("changes %v, unchanged %d, skipped %d; want none, 1 and 2", plan.Changes, plan.Unchanged, plan.Skipped)
+			}
+		})
+	}
+}
+
+func TestPlanRosterBadRoster(t *testing.T) {
+	srv, client := newTestServer(t)
+	srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+
+	entries := []chawk.RosterEntry{
+		{Line: 2, UserName: "a", Role: "Studnet"},
+		{Line: 3, UserName: "A"},
+		{Line: 4},
+		{Line: 5, UserName: "b", Available: "Maybe"},
+	}
+	_, err := client.Courses.PlanRoster(context.Background(), chawk.ByCourseID("BIO-101"), entries, chawk.RosterOptions{})
+	if !errors.Is(err, chawk.ErrInvalidRole) || !errors.Is(err, chawk.ErrInvalidUsername) {
+		t.Fatalf("err = %v, want invalid role and username", err)
+	}
+	for _, want := range []string{"line 3: userName \"A\" is listed twice", "line 5: invalid availability"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestApplyRoster(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+	course := chawk.ByCourseID("BIO-101")
+
+	srv.AddCourse(chawk.Course{CourseID: "BIO-101", Name: "Biology"})
+	for _, u := range []string{"keep", "ta", "gone", "new"} {
+		srv.AddUser(chawk.User{UserName: u})
+	}
+	mustDo(t, srv.Enroll("BIO-101", "keep", ""))
+	mustDo(t, srv.Enroll("BIO-101", "ta", ""))
+	mustDo(t, srv.Enroll("BIO-101", "gone", ""))
+
+	entries := []chawk.RosterEntry{
+		{UserName: "keep"},
+		{UserName: "ta", Role: chawk.RoleTA},
+		{UserName: "new"},
+		{UserName: "ghost"}, // no such user, so its add fails
+	}
+	plan, err := client.Courses.PlanRoster(ctx, course, entries, chawk.RosterOptions{})
+	mustDo(t, err)
+
+	report := client.Courses.ApplyRoster(ctx, plan, 2)
+	if len(report.Results) != len(plan.Changes) {
+		t.Fatalf("%d results for %d changes", len(report.Results), len(plan.Changes))
+	}
+	failed := report.Failed()
+	if len(failed) != 1 || failed[0].UserName != "ghost" {
+		t.Errorf("failed = %v, want only ghost", failed)
+	}
+
+	want := map[string][2]string{
+		"keep": {chawk.RoleStudent, chawk.AvailabilityYes},
+		"ta":   {chawk.RoleTA, chawk.AvailabilityYes},
+		"gone": {chawk.RoleStudent, chawk.AvailabilityDisabled},
+		"new":  {chawk.RoleStudent, chawk.AvailabilityYes},
+	}
+	for user, w := range want {
+		m, ok := srv.Membership("BIO-101", user)
+		if !ok || m.CourseRoleID != w[0] || m.Available != w[1] {
+			t.Errorf("%s: got %q %q (enrolled %v), want %q %q", user, m.CourseRoleID, m.Available, ok, w[0], w[1])
+		}
+	}
+
+	// A second plan finds nothing left to do.
+	again, err := client.Courses.PlanRoster(ctx, course, entries[:3], chawk.RosterOptions{})
+	mustDo(t, err)
+	if len(again.Changes) != 0 {
+		t.Errorf("second plan has changes: %v", again.Changes)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package chawk
+
+import (
+	"os"
+	"time"
+)
+
+// tokenLockStale is how old a lock file has to be before it's taken as
+// left over from a crash. It's well past the longest token fetch the
+// default RetryPolicy allows (4 tries of HTTP_TIMEOUT_SECS plus backoff).
+const tokenLockStale = 5 * time.Minute
+
+// tryLockFile creates path exclusively, for platforms without flock or
+// LockFileEx. It returns a nil unlock if the file already exists. Clearing
+// a stale lock isn't atomic here, so two waiters racing on one can still
+// both get in.
+func tryLockFile(path string) (unlock func(), err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err == nil {
+		file.Close()
+		return func() { os.Remove(path) }, nil
+	}
+	if !os.IsExist(err) {
+		return nil, err
+	}
+
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > tokenLockStale {
+		os.Remove(path)
+	}
+	return nil, nil
+}
@@ -0,0 +1,48 @@
+//go:build windows
+
+package chawk
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	lockfileFailImmediately = 0x1
+	lockfileExclusiveLock   = 0x2
+
+	errLockViolation syscall.Errno = 33 // ERROR_LOCK_VIOLATION
+)
+
+// tryLockFile takes an exclusive LockFileEx lock on path without waiting. It
+// returns a nil unlock if another process holds it.
+func tryLockFile(path string) (unlock func(), err error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	h := file.Fd()
+	ol := new(syscall.Overlapped)
+	r, _, callErr := procLockFileEx.Call(h, lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+	if r == 0 {
+		file.Close()
+		if errors.Is(callErr, errLockViolation) {
+			return nil, nil
+		}
+		return nil, callErr
+	}
+
+	return func() {
+		procUnlockFileEx.Call(h, 0, 1, 0, uintptr(unsafe.Pointer(ol)))
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,200 @@
+package chawk
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// TokenStore caches the OAuth token between runs.
+// Load returns ErrTokenMissing when there is nothing stored yet.
+type TokenStore interface {
+	Load(ctx context.Context) (*Token, error)
+	Save(ctx context.Context, t *Token) error
+}
+
+// TokenLocker is implemented by stores that are shared between processes.
+// The client holds the lock while it checks for, and requests, a new token,
+// so only one worker asks Blackboard for it.
+type TokenLocker interface {
+	Lock(ctx context.Context) (unlock func(), err error)
+}
+
+// MemoryTokenStore keeps the token in memory only. Useful for read-only
+// containers and short lived jobs.
+type MemoryTokenStore struct {
+	mu    sync.Mutex
+	token *Token
+}
+
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{}
+}
+
+func (m *MemoryTokenStore) Load(ctx context.Context) (*Token, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token == nil {
+		return nil, ErrTokenMissing
+	}
+	t := *m.token
+	return &t, nil
+}
+
+func (m *MemoryTokenStore) Save(ctx context.Context, t *Token) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saved := *t
+	m.token = &saved
+	return nil
+}
+
+// tokenLockPoll is how often Lock checks whether the lock is free.
+const tokenLockPoll = 50 * time.Millisecond
+
+// FileTokenStore keeps the token in a JSON file. Writes go to a temp file
+// that is renamed into place, and an OS lock on a sibling ".lock" file keeps
+// several processes from refreshing the token at the same time.
+type FileTokenStore struct {
+	path string
+	aead cipher.AEAD // set for encrypted stores
+}
+
+// NewFileTokenStore returns a store that reads and writes path as plain JSON.
+func NewFileTokenStore(path string) *FileTokenStore {
+	return &FileTokenStore{path: path}
+}
+
+// NewEncryptedFileTokenStore returns a file store that encrypts the token at
+// rest with AES-GCM. key must be 16, 24 or 32 bytes long.
+func NewEncryptedFileTokenStore(path string, key []byte) (*FileTokenStore, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid token key: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	return &FileTokenStore{path: path, aead: aead}, nil
+}
+
+// Path returns the file the token is stored in.
+func (f *FileTokenStore) Path() string {
+	return f.path
+}
+
+func (f *FileTokenStore) Load(ctx context.Context) (*Token, error) {
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrTokenMissing
+		}
+		return nil, fmt.Errorf("failed to open token file: %w", err)
+	}
+
+	if f.aead != nil {
+		size := f.aead.NonceSize()
+		if len(data) < size {
+			return nil, errors.New("token file is too short to be encrypted")
+		}
+		data, err = f.aead.Open(nil, data[:size], data[size:], nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt token file: %w", err)
+		}
+	}
+
+	t := &Token{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("failed to decode token file: %w", err)
+	}
+
+	return t, nil
+}
+
+func (f *FileTokenStore) Save(ctx context.Context, t *Token) error {
+	// Ensure directory exists
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create token directory: %w", err)
+	}
+
+	data, err := json.Marshal(t)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token: %w", err)
+	}
+
+	if f.aead != nil {
+		nonce := make([]byte, f.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return fmt.Errorf("failed to create nonce: %w", err)
+		}
+		data = f.aead.Seal(nonce, nonce, data, nil)
+	}
+
+	// Write next to the real file and rename, so readers never see half a token.
+	tmp, err := os.CreateTemp(dir, filepath.Base(f.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp token file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set token file mode: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write token file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), f.path); err != nil {
+		return fmt.Errorf("failed to replace token file: %w", err)
+	}
+
+	return nil
+}
+
+// Lock takes the store's lock, waiting for other processes to let go of it.
+// It's an OS advisory lock (flock, or LockFileEx on Windows) on a sibling
+// ".lock" file, so it goes away with a process that dies holding it.
+func (f *FileTokenStore) Lock(ctx context.Context) (func(), error) {
+	lockPath := f.path + ".lock"
+
+	if err := os.MkdirAll(filepath.Dir(lockPath), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create token directory: %w", err)
+	}
+
+	for {
+		unlock, err := tryLockFile(lockPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to take token lock: %w", err)
+		}
+		if unlock != nil {
+			return unlock, nil
+		}
+
+		if err := sleepCtx(ctx, tokenLockPoll); err != nil {
+			return nil, err
+		}
+	}
+}
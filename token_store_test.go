@@ -0,0 +1,105 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	encrypted, err := NewEncryptedFileTokenStore(filepath.Join(dir, "enc.json"), make([]byte, 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		store TokenStore
+	}{
+		{"memory", NewMemoryTokenStore()},
+		{"file", NewFileTokenStore(filepath.Join(dir, "sub", "token.json"))},
+		{"encrypted", encrypted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if _, err := tt.store.Load(ctx); !errors.Is(err, ErrTokenMissing) {
+				t.Fatalf("empty store: err = %v, want ErrTokenMissing", err)
+			}
+
+			want := &Token{AccessToken: "abc", TokenType: "bearer", ExpiresIn: 3600}
+			if err := tt.store.Save(ctx, want); err != nil {
+				t.Fatal(err)
+			}
+			got, err := tt.store.Load(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.AccessToken != want.AccessToken || got.ExpiresIn != want.ExpiresIn {
+				t.Errorf("got %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestFileTokenStoreLockIsExclusive(t *testing.T) {
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+	ctx := context.Background()
+
+	var holders atomic.Int32
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock, err := store.Lock(ctx)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if n := holders.Add(1); n > 1 {
+				t.Errorf("%d holders at once", n)
+			}
+			time.Sleep(5 * time.Millisecond)
+			holders.Add(-1)
+			unlock()
+		}()
+	}
+	wg.Wait()
+}
+
+func TestFileTokenStoreLockWaits(t *testing.T) {
+	store := NewFileTokenStore(filepath.Join(t.TempDir(), "token.json"))
+
+	unlock, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An old lock file isn't a stale lock while someone holds it; a token
+	// fetch with retries can easily take longer than a minute.
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(store.Path()+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if _, err := store.Lock(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second Lock: err = %v, want it to wait until the deadline", err)
+	}
+
+	unlock()
+	unlock2, err := store.Lock(context.Background())
+	if err != nil {
+		t.Fatalf("Lock after unlock: %v", err)
+	}
+	unlock2()
+}
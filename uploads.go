@@ -0,0 +1,109 @@
+package chawk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+// UploadService puts files on Learn. An upload on its own does nothing; its
+// ID is handed to another call (an avatar, an attachment) within a short
+// time, after which Learn throws it away.
+type UploadService struct {
+	client *BlackboardClient
+}
+
+type uploadResult struct {
+	ID string `json:"id"`
+}
+
+// Upload streams r to Learn as name and returns the upload ID. The content
+// type comes from name's extension, or is sniffed from the data if it has
+// none. Since the body is streamed it can't be retried.
+func (s *UploadService) Upload(ctx context.Context, name string, r io.Reader) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("file name is required: %w", ErrEmptyStringParameter)
+	}
+
+	br := bufio.NewReader(r)
+	contentType := mime.TypeByExtension(filepath.Ext(name))
+	if contentType == "" {
+		head, _ := br.Peek(512)
+		contentType = http.DetectContentType(head)
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		h := textproto.MIMEHeader{}
+		h.Set("Content-Disposition", mime.FormatMediaType("form-data", map[string]string{"name": "file", "filename": name}))
+		h.Set("Content-Type", contentType)
+
+		part, err := mw.CreatePart(h)
+		if err == nil {
+			_, err = io.Copy(part, br)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	resp, err := s.client.sendWithType(ctx, http.MethodPost, endpoints.Uploads.Create(), mw.FormDataContentType(), pr)
+	// Unblocks the writer if the request ended before reading everything.
+	pr.Close()
+	if err != nil {
+		return "", fmt.Errorf("failed to upload %s: %w", name, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusCreated, http.StatusOK:
+		var res uploadResult
+		if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+			return "", fmt.Errorf("failed to decode upload: %w", err)
+		}
+		s.client.logger.DebugContext(ctx, "file uploaded", "name", name, "content_type", contentType, "upload_id", res.ID)
+		return res.ID, nil
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return "", newAPIError(resp, body, nil)
+	}
+}
+
+// SetAvatar uploads an image from r and makes it user's avatar.
+func (us *UserService) SetAvatar(ctx context.Context, user UserRef, r io.Reader) error {
+	id, err := us.client.Uploads.Upload(ctx, "avatar", r)
+	if err != nil {
+		return err
+	}
+
+	return us.ApplyPatch(ctx, user, UserPatch{
+		Avatar: AvatarPatch{Source: Set("User"), UploadID: Set(id)},
+	})
+}
+
+// SetPronunciationAudio uploads a recording from r of how user's name is
+// said.
+func (us *UserService) SetPronunciationAudio(ctx context.Context, user UserRef, r io.Reader) error {
+	id, err := us.client.Uploads.Upload(ctx, "pronunciation", r)
+	if err != nil {
+		return err
+	}
+
+	return us.ApplyPatch(ctx, user, UserPatch{
+		PronunciationAudio: PronunciationAudioPatch{UploadID: Set(id)},
+	})
+}
@@ -0,0 +1,335 @@
+package chawk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// ImportStatus is what happened to one row of an import. In a dry run it's
+// what would have happened.
+type ImportStatus string
+
+const (
+	ImportCreated   ImportStatus = "created"
+	ImportUpdated   ImportStatus = "updated"
+	ImportUnchanged ImportStatus = "unchanged"
+	ImportFailed    ImportStatus = "failed"
+)
+
+// UserImportOptions controls UserService.Import.
+type UserImportOptions struct {
+	// Columns maps CSV headers to user fields, e.g. {"Email": "contact.email"}.
+	// Without it the headers must be the field names themselves; see
+	// UserImportFields. userName is always required.
+	Columns map[string]string
+
+	// Concurrency is how many rows are imported at once, see
+	// DefaultConcurrency.
+	Concurrency int
+
+	// DryRun looks every user up and reports the changes without making them.
+	DryRun bool
+}
+
+// FieldChange is one field an import changes.
+type FieldChange struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// UserImportResult is the outcome of one CSV row.
+type UserImportResult struct {
+	Line     int // line in the CSV file
+	UserName string
+	Status   ImportStatus
+	Changes  []FieldChange // set for created and updated rows
+	Err      error         // set for failed rows, usually an *APIError
+}
+
+// UserImportReport has one result per CSV row, in file order.
+type UserImportReport struct {
+	DryRun  bool
+	Results []UserImportResult
+}
+
+// Count returns how many rows ended with status.
+func (r *UserImportReport) Count(status ImportStatus) int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Failed returns the rows that failed.
+func (r *UserImportReport) Failed() []UserImportResult {
+	var out []UserImportResult
+	for _, res := range r.Results {
+		if res.Status == ImportFailed {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// importField is one user field an import can set.
+type importField struct {
+	get func(*User) string
+	set func(*User, string)
+
+	// createOnly fields are only used when the user is created.
+	createOnly bool
+}
+
+var importFields = map[string]importField{
+	"userName": {
+		createOnly: true, // it's what rows are matched on
+		get:        func(u *User) string { return u.UserName },
+		set:        func(u *User, v string) { u.UserName = v },
+	},
+	"password": {
+		createOnly: true,
+		get:        func(u *User) string { return u.Password },
+		set:        func(u *User, v string) { u.Password = v },
+	},
+	"dataSourceId": {
+		createOnly: true,
+		get:        func(u *User) string { return u.DataSourceID },
+		set:        func(u *User, v string) { u.DataSourceID = v },
+	},
+	"externalId": {
+		get: func(u *User) string { return u.ExternalID },
+		set: func(u *User, v string) { u.ExternalID = v },
+	},
+	"studentId": {
+		get: func(u *User) string { return u.StudentID },
+		set: func(u *User, v string) { u.StudentID = v },
+	},
+	"name.given": {
+		get: func(u *User) string { return u.Name.Given },
+		set: func(u *User, v string) { u.Name.Given = v },
+	},
+	"name.family": {
+		get: func(u *User) string { return u.Name.Family },
+		set: func(u *User, v string) { u.Name.Family = v },
+	},
+	"contact.email": {
+		get: func(u *User) string { return u.Contact.Email },
+		set: func(u *User, v string) { u.Contact.Email = v },
+	},
+	"contact.institutionEmail": {
+		get: func(u *User) string { return u.Contact.InstitutionEmail },
+		set: func(u *User, v string) { u.Contact.InstitutionEmail = v },
+	},
+	"job.title": {
+		get: func(u *User) string { return u.Job.Title },
+		set: func(u *User, v string) { u.Job.Title = v },
+	},
+	"job.department": {
+		get: func(u *User) string { return u.Job.Department },
+		set: func(u *User, v string) { u.Job.Department = v },
+	},
+	"job.company": {
+		get: func(u *User) string { return u.Job.Company },
+		set: func(u *User, v string) { u.Job.Company = v },
+	},
+	"address.street1": {
+		get: func(u *User) string { return u.Address.Street1 },
+		set: func(u *User, v string) { u.Address.Street1 = v },
+	},
+	"address.street2": {
+		get: func(u *User) string { return u.Address.Street2 },
+		set: func(u *User, v string) { u.Address.Street2 = v },
+	},
+	"address.city": {
+		get: func(u *User) string { return u.Address.City },
+		set: func(u *User, v string) { u.Address.City = v },
+	},
+	"address.state": {
+		get: func(u *User) string { return u.Address.State },
+		set: func(u *User, v string) { u.Address.State = v },
+	},
+	"address.zipCode": {
+		get: func(u *User) string { return u.Address.ZipCode },
+		set: func(u *User, v string) { u.Address.ZipCode = v },
+	},
+	"address.country": {
+		get: func(u *User) string { return u.Address.Country },
+		set: func(u *User, v string) { u.Address.Country = v },
+	},
+	"institutionRoleIds": {
+		get: func(u *User) string {
+			roles := slices.Clone(u.InstitutionRoleIDs)
+			slices.Sort(roles)
+			return strings.Join(roles, listSeparator)
+		},
+		set: func(u *User, v string) { u.InstitutionRoleIDs = splitList(v) },
+	},
+	"availability.available": {
+		get: func(u *User) string { return u.Availability.Available },
+		set: func(u *User, v string) { u.Availability.Available = v },
+	},
+}
+
+// UserImportFields returns the field names Import understands, sorted.
+func UserImportFields() []string {
+	var names []string
+	for name := range importFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Import creates or updates one user per CSV row. Users are matched on
+// userName. An empty cell leaves that field alone, and the password is only
+// used for new users.
+//
+// The returned error is only for problems with the file itself; a row that
+// fails is reported in its result and the import carries on.
+func (us *UserService) Import(ctx context.Context, r io.Reader, opts UserImportOptions) (*UserImportReport, error) {
+	table, err := readCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := importColumns(table, opts.Columns)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UserImportReport{
+		DryRun:  opts.DryRun,
+		Results: make([]UserImportResult, len(table.rows)),
+	}
+
+	forEach(ctx, len(table.rows), opts.Concurrency, func(i int, err error) {
+		row := table.rows[i]
+		if err != nil {
+			report.Results[i] = UserImportResult{Line: row.line, Status: ImportFailed, Err: err}
+			return
+		}
+
+		desired, fields := User{}, []string{}
+		for header, field := range columns {
+			if v := table.get(row, header); v != "" {
+				importFields[field].set(&desired, v)
+				fields = append(fields, field)
+			}
+		}
+		slices.Sort(fields)
+
+		res := us.importUser(ctx, desired, fields, opts.DryRun)
+		res.Line = row.line
+		report.Results[i] = res
+	})
+
+	us.client.logger.InfoContext(ctx, "user import finished",
+		"rows", len(report.Results),
+		"created", report.Count(ImportCreated),
+		"updated", report.Count(ImportUpdated),
+		"unchanged", report.Count(ImportUnchanged),
+		"failed", report.Count(ImportFailed),
+		"dry_run", opts.DryRun)
+
+	return report, nil
+}
+
+// importColumns checks the column mapping against the file and returns
+// header -> field.
+func importColumns(table *csvTable, mapping map[string]string) (map[string]string, error) {
+	columns := map[string]string{}
+
+	if mapping == nil {
+		// Headers are field names; anything else in the file is ignored.
+		for _, h := range table.headers {
+			if _, ok := importFields[h]; ok {
+				columns[h] = h
+			}
+		}
+	} else {
+		for header, field := range mapping {
+			if _, ok := importFields[field]; !ok {
+				return nil, fmt.Errorf("unknown user field %q for column %q", field, header)
+			}
+			if !table.has(header) {
+				return nil, fmt.Errorf("csv has no column %q", header)
+			}
+			columns[header] = field
+		}
+	}
+
+	for _, field := range columns {
+		if field == "userName" {
+			return columns, nil
+		}
+	}
+	return nil, errors.New("no csv column is mapped to userName")
+}
+
+func (us *UserService) importUser(ctx context.Context, desired User, fields []string, dryRun bool) UserImportResult {
+	res := UserImportResult{UserName: desired.UserName}
+	if desired.UserName == "" {
+		res.Status, res.Err = ImportFailed, ErrInvalidUsername
+		return res
+	}
+
+	existing, err := us.Get(ctx, ByUserName(desired.UserName))
+	if errors.Is(err, ErrUserNotFound) {
+		for _, f := range fields {
+			if f == "password" {
+				continue
+			}
+			res.Changes = append(res.Changes, FieldChange{Field: f, New: importFields[f].get(&desired)})
+		}
+
+		res.Status = ImportCreated
+		if !dryRun {
+			if _, err := us.CreateUserPro(ctx, desired); err != nil {
+				res.Status, res.Err = ImportFailed, err
+			}
+		}
+		return res
+	}
+	if err != nil {
+		res.Status, res.Err = ImportFailed, err
+		return res
+	}
+
+	// Only the mapped, non-empty cells are copied over, so everything else
+	// stays as it is and DiffUsers leaves it out of the patch.
+	after := *existing
+	for _, f := range fields {
+		field := importFields[f]
+		if field.createOnly {
+			continue
+		}
+
+		old, new := field.get(existing), field.get(&desired)
+		if old == new {
+			continue
+		}
+		res.Changes = append(res.Changes, FieldChange{Field: f, Old: old, New: new})
+		field.set(&after, new)
+	}
+
+	if len(res.Changes) == 0 {
+		res.Status = ImportUnchanged
+		return res
+	}
+
+	res.Status = ImportUpdated
+	if !dryRun {
+		if err := us.ApplyPatch(ctx, ByPrimaryID(existing.ID), DiffUsers(*existing, after)); err != nil {
+			res.Status, res.Err = ImportFailed, err
+		}
+	}
+	return res
+}
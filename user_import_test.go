@@ -0,0 +1,173 @@
+package chawk_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sugarvoid/chawk"
+	"github.com/sugarvoid/chawk/chawktest"
+)
+
+const importCSV = `userName,name.given,name.family,contact.email,password,notes
+jdoe,Jane,Doe,jdoe@example.edu,,ignored
+asmith,Alice,Smyth,,,
+newbie,New,Person,newbie@example.edu,secret,
+,No,Name,,,
+`
+
+func seedImportUsers(srv *chawktest.Server) {
+	srv.AddUser(chawk.User{
+		UserName: "jdoe",
+		Name:     chawk.Name{Given: "Jane", Family: "Doe"},
+		Contact:  chawk.Contact{Email: "jdoe@example.edu"},
+	})
+	srv.AddUser(chawk.User{
+		UserName: "asmith",
+		Name:     chawk.Name{Given: "Alice", Family: "Smith"},
+		Contact:  chawk.Contact{Email: "asmith@example.edu"},
+	})
+}
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name   string
+		dryRun bool
+	}{
+		{name: "apply"},
+		{name: "dry run", dryRun: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t)
+			seedImportUsers(srv)
+
+			report, err := client.Users.Import(context.Background(), strings.NewReader(importCSV), chawk.UserImportOptions{DryRun: tt.dryRun})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := []struct {
+				line   int
+				user   string
+				status chawk.ImportStatus
+			}{
+				{2, "jdoe", chawk.ImportUnchanged},
+				{3, "asmith", chawk.ImportUpdated},
+				{4, "newbie", chawk.ImportCreated},
+				{5, "", chawk.ImportFailed},
+			}
+			if len(report.Results) != len(want) {
+				t.Fatalf("got %d results, want %d", len(report.Results), len(want))
+			}
+			for i, w := range want {
+				res := report.Results[i]
+				if res.Line != w.line || res.UserName != w.user || res.Status != w.status {
+					t.Errorf("result %d = line %d %q %s (%v), want line %d %q %s", i, res.Line, res.UserName, res.Status, res.Err, w.line, w.user, w.status)
+				}
+			}
+
+			updated := report.Results[1].Changes
+			if len(updated) != 1 || updated[0] != (chawk.FieldChange{Field: "name.family", Old: "Smith", New: "Smyth"}) {
+				t.Errorf("asmith changes = %+v", updated)
+			}
+			for _, c := range report.Results[2].Changes {
+				if c.Field == "password" {
+					t.Error("created row lists the password as a change")
+				}
+			}
+			if failed := report.Failed(); len(failed) != 1 || !errors.Is(failed[0].Err, chawk.ErrInvalidUsername) {
+				t.Errorf("Failed() = %+v", failed)
+			}
+
+			asmith, _ := srv.User("asmith")
+			_, created := srv.User("newbie")
+			if tt.dryRun {
+				if asmith.Name.Family != "Smith" || created {
+					t.Error("dry run changed the server")
+				}
+				return
+			}
+			if asmith.Name.Family != "Smyth" {
+				t.Errorf("asmith family = %q, want Smyth", asmith.Name.Family)
+			}
+			// Empty cells leave the field alone.
+			if asmith.Contact.Email != "asmith@example.edu" {
+				t.Errorf("asmith email = %q, want it unchanged", asmith.Contact.Email)
+			}
+			if !created {
+				t.Error("newbie was not created")
+			}
+		})
+	}
+}
+
+func TestImportColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		csv     string
+		columns map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "mapped headers",
+			csv:     "login,surname\njdoe,Dough\n",
+			columns: map[string]string{"login": "userName", "surname": "name.family"},
+		},
+		{name: "no userName", csv: "name.given\nJane\n", wantErr: true},
+		{
+			name:    "unknown field",
+			csv:     "login,x\njdoe,1\n",
+			columns: map[string]string{"login": "userName", "x": "shoeSize"},
+			wantErr: true,
+		},
+		{
+			name:    "missing column",
+			csv:     "login\njdoe\n",
+			columns: map[string]string{"login": "userName", "surname": "name.family"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t)
+			seedImportUsers(srv)
+
+			report, err := client.Users.Import(context.Background(), strings.NewReader(tt.csv), chawk.UserImportOptions{Columns: tt.columns})
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("want an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Count(chawk.ImportUpdated) != 1 {
+				t.Errorf("updated = %d, want 1", report.Count(chawk.ImportUpdated))
+			}
+			if u, _ := srv.User("jdoe"); u.Name.Family != "Dough" {
+				t.Errorf("jdoe family = %q, want Dough", u.Name.Family)
+			}
+		})
+	}
+}
+
+func TestImportAPIFailure(t *testing.T) {
+	srv, client := newTestServer(t)
+	srv.InjectFault(chawktest.Fault{Method: "POST", Path: "/users", Status: 409})
+
+	report, err := client.Users.Import(context.Background(), strings.NewReader("userName\nnewbie\n"), chawk.UserImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failed := report.Failed()
+	var apiErr *chawk.APIError
+	if len(failed) != 1 || !errors.As(failed[0].Err, &apiErr) || apiErr.StatusCode != 409 {
+		t.Errorf("Failed() = %+v, want one 409", failed)
+	}
+}
@@ -0,0 +1,281 @@
+package chawk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"time"
+
+	endpoints "github.com/sugarvoid/chawk/endpoints"
+)
+
+// UserPatch is a PATCH body for a user covering every field Learn lets you
+// write. Fields left at their zero value aren't sent; use Set to change one
+// and Null to clear it. DiffUsers builds one from two User values.
+type UserPatch struct {
+	UserName           Nullable[string]    `json:"userName,omitzero"`
+	ExternalID         Nullable[string]    `json:"externalId,omitzero"`
+	DataSourceID       Nullable[string]    `json:"dataSourceId,omitzero"`
+	StudentID          Nullable[string]    `json:"studentId,omitzero"`
+	Password           Nullable[string]    `json:"password,omitzero"`
+	EducationLevel     Nullable[string]    `json:"educationLevel,omitzero"`
+	Gender             Nullable[string]    `json:"gender,omitzero"`
+	Pronouns           Nullable[string]    `json:"pronouns,omitzero"`
+	BirthDate          Nullable[time.Time] `json:"birthDate,omitzero"`
+	InstitutionRoleIDs Nullable[[]string]  `json:"institutionRoleIds,omitzero"`
+	SystemRoleIDs      Nullable[[]string]  `json:"systemRoleIds,omitzero"`
+	Pronunciation      Nullable[string]    `json:"pronunciation,omitzero"`
+
+	Availability       AvailabilityPatch       `json:"availability,omitzero"`
+	Name               NamePatch               `json:"name,omitzero"`
+	Job                JobPatch                `json:"job,omitzero"`
+	Contact            ContactPatch            `json:"contact,omitzero"`
+	Address            AddressPatch            `json:"address,omitzero"`
+	Locale             LocalePatch             `json:"locale,omitzero"`
+	Avatar             AvatarPatch             `json:"avatar,omitzero"`
+	PronunciationAudio PronunciationAudioPatch `json:"pronunciationAudio,omitzero"`
+}
+
+type AvailabilityPatch struct {
+	Available Nullable[string] `json:"available,omitzero"`
+}
+
+type NamePatch struct {
+	Given                Nullable[string] `json:"given,omitzero"`
+	Family               Nullable[string] `json:"family,omitzero"`
+	Middle               Nullable[string] `json:"middle,omitzero"`
+	Other                Nullable[string] `json:"other,omitzero"`
+	Suffix               Nullable[string] `json:"suffix,omitzero"`
+	Title                Nullable[string] `json:"title,omitzero"`
+	PreferredDisplayName Nullable[string] `json:"preferredDisplayName,omitzero"`
+}
+
+type JobPatch struct {
+	Title      Nullable[string] `json:"title,omitzero"`
+	Department Nullable[string] `json:"department,omitzero"`
+	Company    Nullable[string] `json:"company,omitzero"`
+}
+
+type ContactPatch struct {
+	HomePhone        Nullable[string] `json:"homePhone,omitzero"`
+	MobilePhone      Nullable[string] `json:"mobilePhone,omitzero"`
+	BusinessPhone    Nullable[string] `json:"businessPhone,omitzero"`
+	BusinessFax      Nullable[string] `json:"businessFax,omitzero"`
+	Email            Nullable[string] `json:"email,omitzero"`
+	InstitutionEmail Nullable[string] `json:"institutionEmail,omitzero"`
+	WebPage          Nullable[string] `json:"webPage,omitzero"`
+}
+
+type AddressPatch struct {
+	Street1 Nullable[string] `json:"street1,omitzero"`
+	Street2 Nullable[string] `json:"street2,omitzero"`
+	City    Nullable[string] `json:"city,omitzero"`
+	State   Nullable[string] `json:"state,omitzero"`
+	ZipCode Nullable[string] `json:"zipCode,omitzero"`
+	Country Nullable[string] `json:"country,omitzero"`
+}
+
+type LocalePatch struct {
+	ID             Nullable[string] `json:"id,omitzero"`
+	Calendar       Nullable[string] `json:"calendar,omitzero"`
+	FirstDayOfWeek Nullable[string] `json:"firstDayOfWeek,omitzero"`
+}
+
+type AvatarPatch struct {
+	Source   Nullable[string] `json:"source,omitzero"`
+	UploadID Nullable[string] `json:"uploadId,omitzero"`
+}
+
+type PronunciationAudioPatch struct {
+	UploadID Nullable[string] `json:"uploadId,omitzero"`
+}
+
+// IsEmpty reports whether the patch changes nothing.
+func (p UserPatch) IsEmpty() bool {
+	return reflect.ValueOf(p).IsZero()
+}
+
+// DiffUsers returns the smallest patch that turns before into after. A field
+// that is empty in after is cleared, except userName, dataSourceId and
+// availability.available, which Learn requires; those are left alone. The
+// password is only sent when after
+// has one, since Learn never returns it. Read-only fields are ignored.
+func DiffUsers(before, after User) UserPatch {
+	var p UserPatch
+
+	p.UserName = diffRequired(before.UserName, after.UserName)
+	p.ExternalID = diffString(before.ExternalID, after.ExternalID)
+	p.DataSourceID = diffRequired(before.DataSourceID, after.DataSourceID)
+	p.StudentID = diffString(before.StudentID, after.StudentID)
+	if after.Password != "" && after.Password != before.Password {
+		p.Password = Set(after.Password)
+	}
+	p.EducationLevel = diffString(before.EducationLevel, after.EducationLevel)
+	p.Gender = diffString(before.Gender, after.Gender)
+	p.Pronouns = diffString(before.Pronouns, after.Pronouns)
+	p.BirthDate = diffTime(before.BirthDate, after.BirthDate)
+	p.InstitutionRoleIDs = diffStrings(before.InstitutionRoleIDs, after.InstitutionRoleIDs)
+	p.SystemRoleIDs = diffStrings(before.SystemRoleIDs, after.SystemRoleIDs)
+	p.Pronunciation = diffString(before.Pronunciation, after.Pronunciation)
+
+	p.Availability.Available = diffRequired(before.Availability.Available, after.Availability.Available)
+
+	p.Name = NamePatch{
+		Given:                diffString(before.Name.Given, after.Name.Given),
+		Family:               diffString(before.Name.Family, after.Name.Family),
+		Middle:               diffString(before.Name.Middle, after.Name.Middle),
+		Other:                diffString(before.Name.Other, after.Name.Other),
+		Suffix:               diffString(before.Name.Suffix, after.Name.Suffix),
+		Title:                diffString(before.Name.Title, after.Name.Title),
+		PreferredDisplayName: diffString(before.Name.PreferredDisplayName, after.Name.PreferredDisplayName),
+	}
+
+	p.Job = JobPatch{
+		Title:      diffString(before.Job.Title, after.Job.Title),
+		Department: diffString(before.Job.Department, after.Job.Department),
+		Company:    diffString(before.Job.Company, after.Job.Company),
+	}
+
+	p.Contact = ContactPatch{
+		HomePhone:        diffString(before.Contact.HomePhone, after.Contact.HomePhone),
+		MobilePhone:      diffString(before.Contact.MobilePhone, after.Contact.MobilePhone),
+		BusinessPhone:    diffString(before.Contact.BusinessPhone, after.Contact.BusinessPhone),
+		BusinessFax:      diffString(before.Contact.BusinessFax, after.Contact.BusinessFax),
+		Email:            diffString(before.Contact.Email, after.Contact.Email),
+		InstitutionEmail: diffString(before.Contact.InstitutionEmail, after.Contact.InstitutionEmail),
+		WebPage:          diffString(before.Contact.WebPage, after.Contact.WebPage),
+	}
+
+	p.Address = AddressPatch{
+		Street1: diffString(before.Address.Street1, after.Address.Street1),
+		Street2: diffString(before.Address.Street2, after.Address.Street2),
+		City:    diffString(before.Address.City, after.Address.City),
+		State:   diffString(before.Address.State, after.Address.State),
+		ZipCode: diffString(before.Address.ZipCode, after.Address.ZipCode),
+		Country: diffString(before.Address.Country, after.Address.Country),
+	}
+
+	p.Locale = LocalePatch{
+		ID:             diffString(before.Locale.ID, after.Locale.ID),
+		Calendar:       diffString(deref(before.Locale.Calendar), deref(after.Locale.Calendar)),
+		FirstDayOfWeek: diffString(deref(before.Locale.FirstDayOfWeek), deref(after.Locale.FirstDayOfWeek)),
+	}
+
+	p.Avatar = AvatarPatch{
+		Source:   diffString(before.Avatar.Source, after.Avatar.Source),
+		UploadID: diffString(before.Avatar.UploadID, after.Avatar.UploadID),
+	}
+	p.PronunciationAudio.UploadID = diffString(before.PronunciationAudio.UploadID, after.PronunciationAudio.UploadID)
+
+	return p
+}
+
+func diffString(before, after string) Nullable[string] {
+	switch {
+	case before == after:
+		return Nullable[string]{}
+	case after == "":
+		return Null[string]()
+	default:
+		return Set(after)
+	}
+}
+
+// diffRequired is diffString for fields that can't be cleared, where an empty
+// after means after didn't set it.
+func diffRequired(before, after string) Nullable[string] {
+	if after == "" {
+		return Nullable[string]{}
+	}
+	return diffString(before, after)
+}
+
+func diffTime(before, after *time.Time) Nullable[time.Time] {
+	switch {
+	case before == nil && after == nil:
+		return Nullable[time.Time]{}
+	case after == nil:
+		return Null[time.Time]()
+	case before != nil && before.Equal(*after):
+		return Nullable[time.Time]{}
+	default:
+		return Set(*after)
+	}
+}
+
+// diffStrings compares role lists ignoring order. An emptied list is sent
+// as [] rather than null, which is what Learn expects for role IDs.
+func diffStrings(before, after []string) Nullable[[]string] {
+	a, b := slices.Clone(before), slices.Clone(after)
+	slices.Sort(a)
+	slices.Sort(b)
+	if slices.Equal(a, b) {
+		return Nullable[[]string]{}
+	}
+	if after == nil {
+		after = []string{}
+	}
+	return Set(after)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// ApplyPatch sends patch to user. An empty patch makes no call.
+func (us *UserService) ApplyPatch(ctx context.Context, user UserRef, patch UserPatch) error {
+	if patch.IsEmpty() {
+		return nil
+	}
+	return us.patch(ctx, user, patch)
+}
+
+// Patch updates the user before was read from so it matches after, sending
+// only the fields that differ. before would normally come from Get.
+//
+//	u, _ := client.Users.Get(ctx, chawk.ByUserName("jdoe"))
+//	changed := *u
+//	changed.Job.Department = "Biology"
+//	changed.Pronouns = ""
+//	err := client.Users.Patch(ctx, *u, changed)
+func (us *UserService) Patch(ctx context.Context, before, after User) error {
+	var user UserRef = ByUserName(before.UserName)
+	if before.ID != "" {
+		user = ByPrimaryID(before.ID)
+	}
+	return us.ApplyPatch(ctx, user, DiffUsers(before, after))
+}
+
+// patch sends body (a UserUpdate or UserPatch) to user.
+func (us *UserService) patch(ctx context.Context, user UserRef, body any) error {
+	path, err := userPath(user)
+	if err != nil {
+		return err
+	}
+
+	resp, err := us.client.Patch(ctx, endpoints.Users.Get(path), body)
+	if err != nil {
+		return fmt.Errorf("failed to update user %s: %w", user, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	case http.StatusNotFound:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, respBody, ErrUserNotFound)
+	case http.StatusConflict:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, respBody, ErrUserExist)
+	default:
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, respBody, nil)
+	}
+}
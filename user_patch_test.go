@@ -0,0 +1,88 @@
+package chawk_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sugarvoid/chawk"
+)
+
+func TestDiffUsers(t *testing.T) {
+	base := chawk.User{
+		UserName:           "jdoe",
+		DataSourceID:       "_2_1",
+		Pronouns:           "they/them",
+		InstitutionRoleIDs: []string{"STUDENT", "ALUMNI"},
+	}
+	base.Availability.Available = chawk.AvailabilityYes
+	base.Job.Department = "Chemistry"
+
+	tests := []struct {
+		name   string
+		change func(u *chawk.User)
+		want   string
+	}{
+		{name: "same", change: func(u *chawk.User) {}, want: `{}`},
+		{name: "set", change: func(u *chawk.User) { u.Job.Department = "Biology" }, want: `{"job":{"department":"Biology"}}`},
+		{name: "clear", change: func(u *chawk.User) { u.Pronouns = "" }, want: `{"pronouns":null}`},
+		{name: "roles reordered", change: func(u *chawk.User) { u.InstitutionRoleIDs = []string{"ALUMNI", "STUDENT"} }, want: `{}`},
+		{name: "roles emptied", change: func(u *chawk.User) { u.InstitutionRoleIDs = nil }, want: `{"institutionRoleIds":[]}`},
+		{name: "rename", change: func(u *chawk.User) { u.UserName = "jdoe2" }, want: `{"userName":"jdoe2"}`},
+		{name: "availability", change: func(u *chawk.User) { u.Availability.Available = chawk.AvailabilityNo }, want: `{"availability":{"available":"No"}}`},
+		{name: "empty userName is left alone", change: func(u *chawk.User) { u.UserName = "" }, want: `{}`},
+		{name: "empty dataSourceId is left alone", change: func(u *chawk.User) { u.DataSourceID = "" }, want: `{}`},
+		{name: "empty availability is left alone", change: func(u *chawk.User) { u.Availability.Available = "" }, want: `{}`},
+		{name: "password only when set", change: func(u *chawk.User) { u.Password = "hunter2" }, want: `{"password":"hunter2"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			after := base
+			after.InstitutionRoleIDs = append([]string(nil), base.InstitutionRoleIDs...)
+			tt.change(&after)
+
+			p := chawk.DiffUsers(base, after)
+			got, err := json.Marshal(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+			if p.IsEmpty() != (tt.want == `{}`) {
+				t.Errorf("IsEmpty = %v", p.IsEmpty())
+			}
+		})
+	}
+}
+
+func TestPatchPartialUser(t *testing.T) {
+	srv, client := newTestServer(t)
+	ctx := context.Background()
+
+	u := chawk.User{UserName: "jdoe"}
+	u.Availability.Available = chawk.AvailabilityYes
+	u.Job.Title = "Lecturer"
+	srv.AddUser(u)
+
+	before, err := client.Users.Get(ctx, chawk.ByUserName("jdoe"))
+	mustDo(t, err)
+
+	// after only has what the caller cares about.
+	var after chawk.User
+	after.Job.Title = "Professor"
+	if body, _ := json.Marshal(chawk.DiffUsers(*before, after)); strings.Contains(string(body), "userName") || strings.Contains(string(body), "available") {
+		t.Fatalf("patch touches required fields: %s", body)
+	}
+	mustDo(t, client.Users.Patch(ctx, *before, after))
+
+	got, ok := srv.User("jdoe")
+	if !ok {
+		t.Fatal("user is gone")
+	}
+	if got.Job.Title != "Professor" || got.Availability.Available != chawk.AvailabilityYes {
+		t.Errorf("job %q, availability %q", got.Job.Title, got.Availability.Available)
+	}
+}
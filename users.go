@@ -6,7 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
@@ -45,6 +49,11 @@ type User struct {
 	Avatar             Avatar             `json:"avatar,omitzero"`
 	Pronunciation      string             `json:"pronunciation,omitempty"`
 	PronunciationAudio PronunciationAudio `json:"pronunciationAudio,omitzero"`
+
+	// Read-only (set by server, ignored on create)
+	Created   *time.Time `json:"created,omitempty"`
+	Modified  *time.Time `json:"modified,omitempty"`
+	LastLogin *time.Time `json:"lastLogin,omitempty"`
 }
 
 type Name struct {
@@ -102,9 +111,15 @@ type PronunciationAudio struct {
 	UploadID string `json:"uploadId,omitempty"`
 }
 
+// UserUpdate is the PATCH body Update sends. It can set fields but not clear
+// them; UserPatch can do both and covers every writable field.
 type UserUpdate struct {
+	ExternalID         *string           `json:"externalId,omitempty"`
+	StudentID          *string           `json:"studentId,omitempty"`
 	Contact            *ContactUpdate    `json:"contact,omitempty"`
 	Name               *NameUpdate       `json:"name,omitempty"`
+	Job                *Job              `json:"job,omitempty"`
+	Address            *Address          `json:"address,omitempty"`
 	Password           *string           `json:"password,omitempty"`
 	InstitutionRoleIDs []string          `json:"institutionRoleIds,omitempty"`
 	Availability       *UserAvailability `json:"availability,omitempty"`
@@ -126,21 +141,23 @@ type CourseEnrollment struct {
 	CourseRoleID string
 	Created      time.Time
 	Name         string
+	Available    string
+
+	AllowObservers bool // whether the course lets observers in
 }
 
-type enrollmentResponse struct {
-	Results []struct {
-		CourseID     string    `json:"courseId"`
-		CourseRoleID string    `json:"courseRoleId"`
-		Created      time.Time `json:"created"`
-		Course       struct {
-			ExternalID string `json:"externalId"`
-			Name       string `json:"name"`
-		} `json:"course"`
-	} `json:"results"`
-	Paging struct {
-		NextPage string `json:"nextPage"`
-	} `json:"paging"`
+type enrollmentResult struct {
+	CourseID     string    `json:"courseId"`
+	CourseRoleID string    `json:"courseRoleId"`
+	Created      time.Time `json:"created"`
+	Course       struct {
+		ExternalID     string `json:"externalId"`
+		Name           string `json:"name"`
+		AllowObservers bool   `json:"allowObservers"`
+	} `json:"course"`
+	Availability struct {
+		Available string `json:"available"`
+	} `json:"availability"`
 }
 
 func (us *UserService) CreateUser(ctx context.Context, username string, fName string, lName string, email string, password string) error {
@@ -173,8 +190,6 @@ func (us *UserService) CreateUser(ctx context.Context, username string, fName st
 		},
 	}
 
-	fmt.Printf("%v\n", data)
-
 	url := endpoints.Users.Create()
 	resp, err := us.client.Post(ctx, url, data)
 	if err != nil {
@@ -183,34 +198,75 @@ func (us *UserService) CreateUser(ctx context.Context, username string, fName st
 	defer resp.Body.Close()
 
 	switch resp.StatusCode {
-	case 201:
+	case http.StatusCreated:
 		return nil
 		//client.Logger.Info(fmt.Sprintf("User %s was created successfully", username))
-	case 403:
-		return ErrInsufficientPrivileges
-		//client.Logger.Error("Insufficient privileges to create a new user")
-	case 409:
-		return ErrUserExist
-		//client.Logger.Error(fmt.Sprintf("User with ID %s already exists", username))
-	case 400:
+	case http.StatusConflict:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return newAPIError(resp, body, ErrUserExist)
+	case http.StatusBadRequest:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
 		if strings.Contains(string(body), "A database error occurred") {
 			// Treat this as a "Potential Conflict/Already Exists"
 			// When testing, a 409 was not being retunred
-			return ErrUserExist
+			return newAPIError(resp, body, ErrUserExist)
 		}
-		//client.Logger.Error(fmt.Sprintf("Error creating user: %s", string(body)))
-		return fmt.Errorf("An error occurred while creating the new user: %s", string(body))
+		return newAPIError(resp, body, nil)
 	default:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
-		//client.Logger.Error(fmt.Sprintf("Unexpected status: %d, response: %s", resp.StatusCode, string(body)))
+		return newAPIError(resp, body, nil)
+	}
+
+}
+
+// CreateUserPro creates u as given and returns the user Learn made. Only
+// UserName is required.
+func (us *UserService) CreateUserPro(ctx context.Context, u User) (*User, error) {
+	u.UserName = strings.TrimSpace(u.UserName)
+	if u.UserName == "" {
+		return nil, ErrInvalidUsername
+	}
+	if u.Availability.Available == "" {
+		u.Availability.Available = AvailabilityYes
+	}
+
+	resp, err := us.client.Post(ctx, endpoints.Users.Create(), u)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		var created User
+		if err := json.Unmarshal(body, &created); err != nil {
+			return nil, fmt.Errorf("failed to parse user response: %w", err)
+		}
+		return &created, nil
+	case http.StatusConflict:
+		return nil, newAPIError(resp, body, ErrUserExist)
+	case http.StatusBadRequest:
+		if strings.Contains(string(body), "A database error occurred") {
+			return nil, newAPIError(resp, body, ErrUserExist)
+		}
+		return nil, newAPIError(resp, body, nil)
+	default:
+		return nil, newAPIError(resp, body, nil)
+	}
 }
 
-func (s *UserService) DoesUserExist(ctx context.Context, username string) (bool, error) {
-	url := endpoints.Users.GetByUsername(username)
+func (s *UserService) DoesUserExist(ctx context.Context, user UserRef) (bool, error) {
+	path, err := userPath(user)
+	if err != nil {
+		return false, err
+	}
+
+	url := endpoints.Users.Get(path)
 
 	resp, err := s.client.Get(ctx, url)
 	if err != nil {
@@ -228,33 +284,38 @@ func (s *UserService) DoesUserExist(ctx context.Context, username string) (bool,
 
 	default:
 		// Any other code (401, 403, 500) is an error, not a "false"
-		return false, fmt.Errorf("unexpected API status: %d", resp.StatusCode)
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
+		return false, newAPIError(resp, body, nil)
 	}
 }
 
-// GetUserObject fetches user data and maps to User struct
-func (us *UserService) GetUserByUsername(ctx context.Context, username string) (*User, error) {
-	username = strings.TrimSpace(username)
-	//exists := us.DoesUserExist(username)
-	//if !exists {
-	//	return nil, fmt.Errorf("someing?")
-	//}
-	url := endpoints.Users.GetByUsername(username)
-	resp, err := us.client.Get(ctx, url)
+// Get fetches a single user.
+func (us *UserService) Get(ctx context.Context, user UserRef) (*User, error) {
+	path, err := userPath(user)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to get user: %d", resp.StatusCode)
+	url := endpoints.Users.Get(path)
+	resp, err := us.client.Get(ctx, url)
+	if err != nil {
+		return nil, err
 	}
+	defer resp.Body.Close()
 
 	body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
 	if err != nil {
 		return nil, err
 	}
 
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, newAPIError(resp, body, ErrUserNotFound)
+	default:
+		return nil, newAPIError(resp, body, nil)
+	}
+
 	var u User
 	if err := json.Unmarshal(body, &u); err != nil {
 		return nil, err
@@ -262,66 +323,68 @@ func (us *UserService) GetUserByUsername(ctx context.Context, username string) (
 	return &u, nil
 }
 
-func (us *UserService) Update(ctx context.Context, username string, update UserUpdate) error {
-	username = strings.TrimSpace(username)
-	if username == "" {
-		return ErrInvalidUsername
-	}
+func (us *UserService) GetUserByUsername(ctx context.Context, username string) (*User, error) {
+	return us.Get(ctx, ByUserName(username))
+}
 
-	url := endpoints.Users.GetByUsername(username)
+// Delete removes a user account for good. To take an account away in a way
+// that can be undone, use Offboard.
+func (us *UserService) Delete(ctx context.Context, user UserRef) error {
+	path, err := userPath(user)
+	if err != nil {
+		return err
+	}
 
-	resp, err := us.client.Patch(ctx, url, update)
+	resp, err := us.client.Delete(ctx, endpoints.Users.Get(path))
 	if err != nil {
-		return err // transport / marshal / network
+		return fmt.Errorf("request failed deleting user %s: %w", user, err)
 	}
 	defer resp.Body.Close()
 
 	switch resp.StatusCode {
-	case http.StatusOK, http.StatusNoContent:
+	case http.StatusNoContent, http.StatusOK:
+		us.client.logger.InfoContext(ctx, "user deleted", "user", user)
 		return nil
-
 	case http.StatusNotFound:
-		return ErrUserNotFound
-
-	case http.StatusBadRequest:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf("invalid user update: %s", string(body))
-
+		return newAPIError(resp, body, ErrUserNotFound)
 	default:
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		return fmt.Errorf(
-			"update user failed: status=%d body=%s",
-			resp.StatusCode,
-			string(body),
-		)
+		return newAPIError(resp, body, nil)
 	}
 }
 
-func (us *UserService) UpdatePassword(ctx context.Context, username, newPassword string) error {
+// Update sends update to user. Fields left nil aren't changed; to clear a
+// field use ApplyPatch with a UserPatch instead.
+func (us *UserService) Update(ctx context.Context, user UserRef, update UserUpdate) error {
+	return us.patch(ctx, user, update)
+}
+
+func (us *UserService) UpdatePassword(ctx context.Context, user UserRef, newPassword string) error {
 	password := strings.TrimSpace(newPassword)
 	if password == "" {
 		return errors.New("new password cannot be empty")
 	}
 
-	return us.Update(ctx, username, UserUpdate{
+	return us.Update(ctx, user, UserUpdate{
 		Password: ToPtr(password),
 	})
 }
 
-func (us *UserService) UpdateEmail(ctx context.Context, username, newEmail string) error {
+func (us *UserService) UpdateEmail(ctx context.Context, user UserRef, newEmail string) error {
 	email := strings.TrimSpace(newEmail)
 
-	return us.Update(ctx, username, UserUpdate{
+	return us.Update(ctx, user, UserUpdate{
 		Contact: &ContactUpdate{
 			Email: ToPtr(email),
 		},
 	})
 }
 
-func (us *UserService) UpdateInstitutionEmail(ctx context.Context, username, newEmail string) error {
+func (us *UserService) UpdateInstitutionEmail(ctx context.Context, user UserRef, newEmail string) error {
 	email := strings.TrimSpace(newEmail)
 
-	return us.Update(ctx, username, UserUpdate{
+	return us.Update(ctx, user, UserUpdate{
 		Contact: &ContactUpdate{
 			InstitutionEmail: ToPtr(email),
 		},
@@ -341,7 +404,7 @@ func (us *UserService) UpdateInstitutionEmail(ctx context.Context, username, new
 // 	return uc.updateUser(username, data, "name changed")
 // }
 
-func (us *UserService) UpdateName(ctx context.Context, username, fName, lName string) error {
+func (us *UserService) UpdateName(ctx context.Context, user UserRef, fName, lName string) error {
 	fName = strings.TrimSpace(fName)
 	lName = strings.TrimSpace(lName)
 
@@ -357,20 +420,27 @@ func (us *UserService) UpdateName(ctx context.Context, username, fName, lName st
 		update.Family = &lName
 	}
 
-	return us.Update(ctx, username, UserUpdate{Name: update})
+	return us.Update(ctx, user, UserUpdate{Name: update})
 }
 
-func (us *UserService) AddInstitutionRoles(ctx context.Context, username string, roles []string) error {
+// AddInstitutionRoles sets the user's secondary institution roles. Each one is
+// checked against the server's catalog first, see RoleService.
+func (us *UserService) AddInstitutionRoles(ctx context.Context, user UserRef, roles []string) error {
 	if len(roles) == 0 {
 		return errors.New("no roles provided")
 	}
+	for _, role := range roles {
+		if err := us.client.Roles.ValidateInstitutionRole(ctx, role); err != nil {
+			return err
+		}
+	}
 
-	return us.Update(ctx, username, UserUpdate{
+	return us.Update(ctx, user, UserUpdate{
 		InstitutionRoleIDs: roles,
 	})
 }
 
-func (us *UserService) UpdateUserAvailability(ctx context.Context, username string, availability string) error {
+func (us *UserService) UpdateUserAvailability(ctx context.Context, user UserRef, availability string) error {
 	//availability = strings.TrimSpace(availability)
 
 	switch availability {
@@ -384,53 +454,227 @@ func (us *UserService) UpdateUserAvailability(ctx context.Context, username stri
 	// 	return ErrUserNotFound
 	// }
 
-	return us.Update(ctx, username, UserUpdate{
+	return us.Update(ctx, user, UserUpdate{
 		Availability: &UserAvailability{Available: availability},
 	})
 }
 
-func (us *UserService) GetCourses(ctx context.Context, username string) ([]CourseEnrollment, error) {
-	//url := fmt.Sprintf("/learn/api/public/v1/users/userName:%s/courses?expand=course&fields=courseId,courseRoleId,created,course.externalId,course.name", username)
-	url := endpoints.Users.GetMemberships(username)
+func (us *UserService) GetCourses(ctx context.Context, user UserRef) ([]CourseEnrollment, error) {
+	path, err := userPath(user)
+	if err != nil {
+		return nil, err
+	}
+
+	url := endpoints.Users.GetMemberships(path)
+
+	pager := NewPager[enrollmentResult](us.client, url)
+	pager.NotFound = ErrUserNotFound
 
 	var allEnrollments []CourseEnrollment
 
-	for {
-		resp, err := us.client.Get(ctx, url)
+	for r, err := range pager.All(ctx) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to get user courses: %w", err)
 		}
 
-		body, err := io.ReadAll(io.LimitReader(resp.Body, MAX_RESPONSE_SIZE))
-		resp.Body.Close()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
+		allEnrollments = append(allEnrollments, CourseEnrollment{
+			CourseID:     r.CourseID,
+			ExternalID:   r.Course.ExternalID,
+			CourseRoleID: r.CourseRoleID,
+			Created:      r.Created,
+			Name:         r.Course.Name,
+			Available:    r.Availability.Available,
+
+			AllowObservers: r.Course.AllowObservers,
+		})
+	}
+
+	return allEnrollments, nil
+}
+
+// UserQuery filters UserService.List. The text filters match anywhere in the
+// field, ignoring case. Zero values are left out.
+type UserQuery struct {
+	UserName   string
+	ExternalID string
+	StudentID  string
+	GivenName  string
+	FamilyName string
+	Email      string
+
+	// After bounds include the time itself and Before bounds don't. Learn
+	// only takes one bound per date, so CreatedAfter/ModifiedAfter are sent
+	// to the server and the Before bounds are checked here. Set only a
+	// Before bound and it is sent instead.
+	CreatedAfter   time.Time
+	CreatedBefore  time.Time
+	ModifiedAfter  time.Time
+	ModifiedBefore time.Time
+
+	InstitutionRoleIDs []string
+	Available          string // AvailabilityYes, AvailabilityNo or AvailabilityDisabled
+	DataSourceID       string
+
+	// Sort is a field name, with "(desc)" for descending, e.g. "created(desc)".
+	Sort string
+	// Fields limits the fields returned for each user.
+	Fields []string
+
+	PageSize int // results per request, 0 for the server default
+	MaxItems int // stop after this many users, 0 for all
+}
+
+// dateBound is one of the Created/Modified ranges of a UserQuery. After is
+// inclusive and Before exclusive, as Learn's compare values are.
+type dateBound struct {
+	param         string
+	after, before time.Time
+}
+
+// server adds the query parameters for the bound Learn can filter on, and
+// reports whether match still has to be checked client side. Learn only
+// takes whole seconds, so a bound with a fraction is widened to the next
+// second and checked here too.
+func (b dateBound) server(v url.Values) (clientSide bool) {
+	switch {
+	case !b.after.IsZero():
+		v.Set(b.param, b.after.UTC().Format(time.RFC3339))
+		v.Set(b.param+"Compare", "greaterOrEqual")
+		return !b.before.IsZero() || !b.after.Equal(b.after.Truncate(time.Second))
+	case !b.before.IsZero():
+		v.Set(b.param, b.before.Add(time.Second-1).UTC().Format(time.RFC3339))
+		v.Set(b.param+"Compare", "lessThan")
+		return !b.before.Equal(b.before.Truncate(time.Second))
+	}
+	return false
+}
+
+// match reports whether t is within the bound. A missing date never is.
+func (b dateBound) match(t *time.Time) bool {
+	if t == nil {
+		return false
+	}
+	if !b.after.IsZero() && t.Before(b.after) {
+		return false
+	}
+	return b.before.IsZero() || t.Before(b.before)
+}
+
+func (q UserQuery) validate() error {
+	switch q.Available {
+	case "", AvailabilityYes, AvailabilityNo, AvailabilityDisabled:
+	default:
+		return fmt.Errorf("invalid availability %q", q.Available)
+	}
+
+	if !q.CreatedAfter.IsZero() && !q.CreatedBefore.IsZero() && q.CreatedAfter.After(q.CreatedBefore) {
+		return errors.New("CreatedAfter is after CreatedBefore")
+	}
+	if !q.ModifiedAfter.IsZero() && !q.ModifiedBefore.IsZero() && q.ModifiedAfter.After(q.ModifiedBefore) {
+		return errors.New("ModifiedAfter is after ModifiedBefore")
+	}
+	return nil
+}
+
+// path builds the first page's path. It also returns the checks that
+// Learn can't do itself.
+func (q UserQuery) path() (string, func(User) bool) {
+	v := url.Values{}
+
+	text := map[string]string{
+		"userName":               q.UserName,
+		"externalId":             q.ExternalID,
+		"studentId":              q.StudentID,
+		"name.given":             q.GivenName,
+		"name.family":            q.FamilyName,
+		"contact.email":          q.Email,
+		"dataSourceId":           q.DataSourceID,
+		"availability.available": q.Available,
+		"sort":                   q.Sort,
+	}
+	for k, val := range text {
+		if val = strings.TrimSpace(val); val != "" {
+			v.Set(k, val)
 		}
+	}
 
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	if len(q.InstitutionRoleIDs) > 0 {
+		v.Set("institutionRoleIds", strings.Join(q.InstitutionRoleIDs, ","))
+	}
+	if q.PageSize > 0 {
+		v.Set("limit", strconv.Itoa(q.PageSize))
+	}
+
+	created := dateBound{"created", q.CreatedAfter, q.CreatedBefore}
+	modified := dateBound{"modified", q.ModifiedAfter, q.ModifiedBefore}
+	checkCreated, checkModified := created.server(v), modified.server(v)
+
+	if len(q.Fields) > 0 {
+		fields := slices.Clone(q.Fields)
+		// The client side checks need the dates even if they weren't asked for.
+		if checkCreated && !slices.Contains(fields, "created") {
+			fields = append(fields, "created")
 		}
+		if checkModified && !slices.Contains(fields, "modified") {
+			fields = append(fields, "modified")
+		}
+		v.Set("fields", strings.Join(fields, ","))
+	}
 
-		var result enrollmentResponse
-		if err := json.Unmarshal(body, &result); err != nil {
-			return nil, fmt.Errorf("failed to parse response: %w", err)
+	keep := func(u User) bool {
+		if checkCreated && !created.match(u.Created) {
+			return false
+		}
+		if checkModified && !modified.match(u.Modified) {
+			return false
 		}
+		return true
+	}
+
+	path := endpoints.Users.List()
+	if len(v) > 0 {
+		path += "?" + v.Encode()
+	}
+	return path, keep
+}
 
-		for _, r := range result.Results {
-			allEnrollments = append(allEnrollments, CourseEnrollment{
-				CourseID:     r.CourseID,
-				ExternalID:   r.Course.ExternalID,
-				CourseRoleID: r.CourseRoleID,
-				Created:      r.Created,
-				Name:         r.Course.Name,
-			})
+// List yields every user matching q, fetching pages only as the loop asks
+// for them, so it's fine to walk every account in an instance.
+//
+//	for u, err := range client.Users.List(ctx, chawk.UserQuery{FamilyName: "smith"}) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(u.UserName)
+//	}
+func (us *UserService) List(ctx context.Context, q UserQuery) iter.Seq2[User, error] {
+	return func(yield func(User, error) bool) {
+		if err := q.validate(); err != nil {
+			yield(User{}, err)
+			return
 		}
 
-		if result.Paging.NextPage == "" {
-			break
+		path, keep := q.path()
+		pager := NewPager[User](us.client, path)
+
+		matched := 0
+		for u, err := range pager.All(ctx) {
+			if err != nil {
+				yield(User{}, fmt.Errorf("failed to list users: %w", err))
+				return
+			}
+			if !keep(u) {
+				continue
+			}
+
+			if !yield(u, nil) {
+				return
+			}
+
+			matched++
+			if q.MaxItems > 0 && matched >= q.MaxItems {
+				return
+			}
 		}
-		url = result.Paging.NextPage
 	}
-
-	return allEnrollments, nil
 }
@@ -0,0 +1,131 @@
+package chawk_test
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sugarvoid/chawk"
+	"github.com/sugarvoid/chawk/chawktest"
+)
+
+// lastQuery returns the raw query of the last request made to path.
+func lastQuery(t *testing.T, srv *chawktest.Server, path string) string {
+	t.Helper()
+	reqs := srv.Requests()
+	for i := len(reqs) - 1; i >= 0; i-- {
+		if reqs[i].Path == path {
+			return reqs[i].Query
+		}
+	}
+	t.Fatalf("no request to %s", path)
+	return ""
+}
+
+func TestUserQueryString(t *testing.T) {
+	day := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		q    chawk.UserQuery
+		want string
+	}{
+		{
+			name: "after",
+			q:    chawk.UserQuery{CreatedAfter: day},
+			want: "created=2025-07-01T00%3A00%3A00Z&createdCompare=greaterOrEqual",
+		},
+		{
+			name: "before",
+			q:    chawk.UserQuery{ModifiedBefore: day},
+			want: "modified=2025-07-01T00%3A00%3A00Z&modifiedCompare=lessThan",
+		},
+		{
+			name: "before with a fraction rounds up",
+			q:    chawk.UserQuery{CreatedBefore: day.Add(300 * time.Millisecond)},
+			want: "created=2025-07-01T00%3A00%3A01Z&createdCompare=lessThan",
+		},
+		{
+			name: "both sends after",
+			q:    chawk.UserQuery{CreatedAfter: day, CreatedBefore: day.AddDate(0, 1, 0), Fields: []string{"userName"}},
+			want: "created=2025-07-01T00%3A00%3A00Z&createdCompare=greaterOrEqual&fields=userName%2Ccreated",
+		},
+		{
+			name: "filters",
+			q:    chawk.UserQuery{FamilyName: " smith ", Available: chawk.AvailabilityYes, InstitutionRoleIDs: []string{"STUDENT", "STAFF"}, PageSize: 10},
+			want: "availability.available=Yes&institutionRoleIds=STUDENT%2CSTAFF&limit=10&name.family=smith",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, client := newTestServer(t)
+			for _, err := range client.Users.List(context.Background(), tt.q) {
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := lastQuery(t, srv, "/learn/api/public/v1/users"); got != tt.want {
+				t.Errorf("query\n got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUserListDates(t *testing.T) {
+	srv, client := newTestServer(t)
+	t0 := time.Date(2025, 7, 1, 12, 0, 0, 0, time.UTC)
+	for name, created := range map[string]time.Time{
+		"early": t0.Add(-time.Hour),
+		"exact": t0,
+		"frac":  t0.Add(700 * time.Millisecond),
+		"late":  t0.Add(time.Hour),
+	} {
+		srv.AddUser(chawk.User{UserName: name, Created: &created})
+	}
+
+	tests := []struct {
+		name string
+		q    chawk.UserQuery
+		want []string
+	}{
+		{name: "before is exclusive", q: chawk.UserQuery{CreatedBefore: t0}, want: []string{"early"}},
+		{name: "after is inclusive", q: chawk.UserQuery{CreatedAfter: t0}, want: []string{"exact", "frac", "late"}},
+		{name: "range", q: chawk.UserQuery{CreatedAfter: t0.Add(-time.Hour), CreatedBefore: t0.Add(time.Hour)}, want: []string{"early", "exact", "frac"}},
+		{name: "before with a fraction", q: chawk.UserQuery{CreatedBefore: t0.Add(500 * time.Millisecond)}, want: []string{"early", "exact"}},
+		{name: "after with a fraction", q: chawk.UserQuery{CreatedAfter: t0.Add(500 * time.Millisecond)}, want: []string{"frac", "late"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for u, err := range client.Users.List(context.Background(), tt.q) {
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, u.UserName)
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFakeRejectsBadCompare(t *testing.T) {
+	_, client := newTestServer(t)
+
+	for _, compare := range []string{"lessOrEqual", "greaterThan", "equal"} {
+		resp, err := client.Get(context.Background(), "/learn/api/public/v1/users?created=2025-07-01T00:00:00Z&createdCompare="+compare)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("createdCompare=%s: status %d, want 400", compare, resp.StatusCode)
+		}
+	}
+}